@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// runBuild merges a base spec with overlays and builds the result
+func runBuild(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p parserInterface, b builderInterface) int {
+	flags := flag.NewFlagSet("buildtree build", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var assignments stringList
+	helpFlag := flags.Bool("help", false, "Show help")
+	valuesFile := flags.String("values", "", "YAML file with template values")
+	dryRun := flags.Bool("dry-run", false, "Print the merged structure without creating it")
+	onType := flags.String("on-type-conflict", string(buildtree.MergeError), "When a path is a file in one spec and a directory in another: error, first or last")
+	onContent := flags.String("on-content-conflict", string(buildtree.MergeLast), "When specs give a file different contents: error, first or last")
+	bf := newBuildFlags(flags)
+	flags.Var(&assignments, "set", "Set a template value as key=value (repeatable)")
+	flags.BoolVar(helpFlag, "h", false, "Alias for --help")
+
+	specs, err := parseInterspersed(flags, args)
+	if err != nil {
+		return 1
+	}
+
+	if *helpFlag {
+		printBuildHelp(stdout)
+		return 0
+	}
+	if err := bf.check(); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	var mergeOpts buildtree.MergeOptions
+	if mergeOpts.Types, err = mergePolicy("--on-type-conflict", *onType); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if mergeOpts.Contents, err = mergePolicy("--on-content-conflict", *onContent); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(specs) == 0 {
+		printBuildHelp(stderr)
+		fmt.Fprintln(stderr, "Error: No spec file provided")
+		return 1
+	}
+
+	// Every spec is expanded with the same values before merging
+	values, err := templateValues(*valuesFile, assignments)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error reading values: %v\n", err)
+		return 1
+	}
+	ctx := context.Background()
+	trees := make([]*buildtree.Node, 0, len(specs))
+	for _, spec := range specs {
+		tree, err := buildtree.ParseFile(ctx, spec, buildtree.ParseOptions{Values: values})
+		if err != nil {
+			fmt.Fprintf(stderr, "Error reading %s: %v\n", spec, err)
+			return 1
+		}
+		trees = append(trees, tree)
+	}
+	root, err := buildtree.Merge(mergeOpts, trees...)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error merging specs: %v\n", err)
+		return 1
+	}
+	return bf.build(stdout, stderr, b, root, *dryRun)
+}
+
+// mergePolicy parses the value of a conflict flag
+func mergePolicy(flagName, value string) (buildtree.MergePolicy, error) {
+	for _, policy := range buildtree.MergePolicies {
+		if string(policy) == value {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("%s must be error, first or last, got %q", flagName, value)
+}
+
+func printBuildHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: buildtree build [OPTIONS] BASE [OVERLAY...]")
+	fmt.Fprintln(w, "Merge a base spec with overlays, matching paths below the roots, and build the result")
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  --on-type-conflict P	File in one spec, directory in another: error (default), first or last")
+	fmt.Fprintln(w, "  --on-content-conflict P	Different contents for a file: error, first or last (default)")
+	fmt.Fprintln(w, "  --set KEY=VALUE	Set a template value (repeatable)")
+	fmt.Fprintln(w, "  --values FILE		Read template values from a YAML file")
+	fmt.Fprintln(w, "  --dry-run		Print the merged structure without creating it")
+	printBuildFlagsHelp(w)
+	fmt.Fprintln(w, "  -d, --max-depth N	Maximum nesting depth allowed (0=unlimited, default:20)")
+	fmt.Fprintln(w, "  -h, --help		Show this help message")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunBuild(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeSpec(t, "base.tree", "app/\n├── cmd/\n│   └── main.go\n└── go.mod\n")
+	writeSpec(t, "team.tree", "app/\n├── cmd/\n│   └── worker.go\n└── OWNERS\n")
+	writeSpec(t, "ci.yaml", "name: app\ntype: dir\nchildren:\n  - name: .github\n    type: dir\n")
+
+	stderr := &bytes.Buffer{}
+	args := []string{"build", "base.tree", "team.tree", "ci.yaml"}
+	if code := run(args, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &realBuilder{}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	for _, path := range []string{"app/cmd/main.go", "app/cmd/worker.go", "app/go.mod", "app/OWNERS", "app/.github"} {
+		if _, err := os.Stat(filepath.FromSlash(path)); err != nil {
+			t.Errorf("Expected %s to exist: %v", path, err)
+		}
+	}
+}
+
+func TestRunBuild_Conflicts(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeSpec(t, "base.tree", "app/\n├── docs/\n└── Makefile\n")
+	writeSpec(t, "overlay.tree", "app/\n├── docs/\n└── Makefile/\n    └── rules.mk\n")
+
+	tests := []struct {
+		args   []string
+		code   int
+		output string
+	}{
+		{[]string{}, 1, "Error merging specs: app/Makefile: file and directory with the same path (overlay.tree:3)"},
+		{[]string{"--on-type-conflict", "first"}, 0, "app/\n├── docs/\n└── Makefile\n"},
+		{[]string{"--on-type-conflict=last"}, 0, "app/\n├── docs/\n└── Makefile/\n    └── rules.mk\n"},
+		{[]string{"--on-content-conflict", "newest"}, 1, "--on-content-conflict must be error, first or last"},
+	}
+
+	for _, tt := range tests {
+		args := append(append([]string{"build", "--dry-run"}, tt.args...), "base.tree", "overlay.tree")
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		if code := run(args, &bytes.Buffer{}, stdout, stderr, &realParser{}, &realBuilder{}); code != tt.code {
+			t.Fatalf("%v: expected exit code %d, got %d (stderr: %s)", tt.args, tt.code, code, stderr.String())
+		}
+		if tt.code == 0 && stdout.String() != tt.output {
+			t.Errorf("%v: expected\n%s\ngot\n%s", tt.args, tt.output, stdout.String())
+		}
+		if tt.code != 0 && !strings.Contains(stderr.String(), tt.output) {
+			t.Errorf("%v: expected %q in stderr, got %q", tt.args, tt.output, stderr.String())
+		}
+	}
+}
+
+func TestRunBuild_NoSpecs(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	if code := run([]string{"build"}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &realBuilder{}); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Error: No spec file provided") {
+		t.Errorf("Unexpected stderr %q", stderr.String())
+	}
+}
+
+func writeSpec(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// buildFlags are the options shared by every command that builds a tree
+type buildFlags struct {
+	maxDepth     int
+	jobs         int
+	skeletons    bool
+	skeletonDirs stringList
+	keepEmpty    optionalString
+	portable     bool
+	reportFormat string
+
+	selection *selectFlags
+	repo      *repoFlags
+	stop      *interruptFlags
+}
+
+func newBuildFlags(flags *flag.FlagSet) *buildFlags {
+	b := &buildFlags{}
+	b.register(flags)
+	return b
+}
+
+// register adds the build options to flags
+func (b *buildFlags) register(flags *flag.FlagSet) {
+	b.keepEmpty.fallback = buildtree.DefaultPlaceholder
+	flags.IntVar(&b.maxDepth, "max-depth", 20, "Maximum nesting depth allowed (0 = no limit)")
+	flags.IntVar(&b.maxDepth, "d", 20, "Alias for --max-depth")
+	flags.BoolVar(&b.skeletons, "skeletons", false, "Fill empty files from per-extension skeletons")
+	flags.Var(&b.skeletonDirs, "skeleton-dir", "Directory of skeletons searched before the defaults (repeatable)")
+	flags.Var(&b.keepEmpty, "keep-empty", "Add a placeholder file (default .gitkeep) to empty directories")
+	b.selection = newSelectFlags(flags)
+	flags.BoolVar(&b.portable, "portable", false, "Fail on sibling names that clash on macOS or Windows")
+	b.repo = newRepoFlags(flags)
+	b.stop = newInterruptFlags(flags)
+	flags.StringVar(&b.reportFormat, "report", "", "Print a build report in FORMAT (json)")
+	flags.IntVar(&b.jobs, "jobs", 1, "Number of paths created in parallel (0 = number of CPUs)")
+	flags.IntVar(&b.jobs, "j", 1, "Alias for --jobs")
+}
+
+// printBuildFlagsHelp lists the build options except --max-depth, which
+// each command places itself
+func printBuildFlagsHelp(w io.Writer) {
+	fmt.Fprintln(w, "  --skeletons		Fill empty files from per-extension skeletons")
+	fmt.Fprintln(w, "  --skeleton-dir DIR	Search DIR for skeletons first (repeatable, implies --skeletons)")
+	fmt.Fprintln(w, "  --keep-empty[=NAME]	Add NAME (default .gitkeep) to empty directories")
+	printSelectHelp(w)
+	fmt.Fprintln(w, "  --portable		Fail on duplicate names and names differing only in case or NFC/NFD")
+	printRepoHelp(w)
+	printInterruptHelp(w)
+	fmt.Fprintln(w, "  --report json		Print the outcome of every path as JSON")
+	fmt.Fprintln(w, "  -j, --jobs N		Create N paths in parallel (0=number of CPUs, default:1)")
+}
+
+// check rejects invalid or contradictory options and resolves --jobs 0
+// to the number of CPUs
+func (b *buildFlags) check() error {
+	if err := checkReportFormat(b.reportFormat); err != nil {
+		return err
+	}
+	if b.jobs < 0 {
+		return errors.New("--jobs must not be negative")
+	}
+	if b.jobs == 0 {
+		b.jobs = runtime.NumCPU()
+	}
+	return b.selection.check()
+}
+
+// options returns the builder options for the flags
+func (b *buildFlags) options() (buildtree.BuildOptions, error) {
+	content, err := contentProvider(b.skeletons, b.skeletonDirs)
+	if err != nil {
+		return buildtree.BuildOptions{}, err
+	}
+	return buildtree.BuildOptions{
+		MaxDepth:    b.maxDepth,
+		Content:     content,
+		Placeholder: b.keepEmpty.value,
+		Jobs:        b.jobs,
+		NoRoot:      b.selection.noRoot,
+	}, nil
+}
+
+// build selects the paths of a resolved tree and checks their names, then
+// prints the tree for --dry-run or builds it and sets up the repository
+func (b *buildFlags) build(stdout, stderr io.Writer, builder builderInterface, root *buildtree.Node, dryRun bool) int {
+	if err := b.selection.apply(root); err != nil {
+		fmt.Fprintf(stderr, "Error selecting paths: %v\n", err)
+		return 1
+	}
+	if code := checkPortable(stderr, root, b.portable); code != 0 {
+		return code
+	}
+
+	if dryRun {
+		return printDryRun(stdout, stderr, root)
+	}
+
+	opts, err := b.options()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error locating skeletons: %v\n", err)
+		return 1
+	}
+	ctx, cancel := b.stop.context(context.Background())
+	defer cancel()
+	build := func(opts buildtree.BuildOptions) (*buildtree.Report, error) {
+		return builder.BuildTree(ctx, root, opts)
+	}
+	if code := buildTree(stdout, stderr, build, b.repo.track(opts), b.reportFormat, b.stop, countPaths(root)); code != 0 {
+		return code
+	}
+
+	return setupRepo(stderr, b.selection.dir(root), root, b.repo)
+}
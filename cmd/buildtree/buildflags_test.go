@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBuildFlags_Check(t *testing.T) {
+	tests := []struct {
+		args  []string
+		jobs  int
+		error string
+	}{
+		{nil, 1, ""},
+		{[]string{"-j", "4"}, 4, ""},
+		{[]string{"--jobs", "0"}, runtime.NumCPU(), ""},
+		{[]string{"--jobs", "-1"}, 0, "--jobs must not be negative"},
+		{[]string{"--report", "xml"}, 0, `unknown report format "xml"`},
+		{[]string{"--root-as", "app", "--no-root"}, 0, "--root-as and --no-root cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		bf := newBuildFlags(flags)
+		if err := flags.Parse(tt.args); err != nil {
+			t.Fatalf("%v: unexpected parse error: %v", tt.args, err)
+		}
+
+		err := bf.check()
+		if tt.error != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("%v: expected error %q, got %v", tt.args, tt.error, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.args, err)
+		}
+		if bf.jobs != tt.jobs {
+			t.Errorf("%v: expected %d jobs, got %d", tt.args, tt.jobs, bf.jobs)
+		}
+	}
+}
+
+func TestBuildFlags_Options(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	bf := newBuildFlags(flags)
+	if err := flags.Parse([]string{"-d", "3", "--keep-empty", "--no-root"}); err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+
+	opts, err := bf.options()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts.MaxDepth != 3 || opts.Placeholder != ".gitkeep" || !opts.NoRoot || opts.Content != nil {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+}
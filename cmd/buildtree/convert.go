@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// runConvert translates a structure between the supported formats
+func runConvert(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p parserInterface, b builderInterface) int {
+	flags := flag.NewFlagSet("buildtree convert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	helpFlag := flags.Bool("help", false, "Show help")
+	from := flags.String("from", "", "Input format (default: detected from the file extension)")
+	to := flags.String("to", "", "Output format")
+	flags.BoolVar(helpFlag, "h", false, "Alias for --help")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if *helpFlag {
+		printConvertHelp(stdout)
+		return 0
+	}
+
+	if *to == "" {
+		printConvertHelp(stderr)
+		fmt.Fprintln(stderr, "Error: No output format provided")
+		return 1
+	}
+	outFormat, err := buildtree.LookupFormat(*to)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	var input []byte
+	inFormat := buildtree.FormatTree
+	if flags.NArg() > 0 {
+		input, err = os.ReadFile(flags.Arg(0))
+		inFormat = buildtree.DetectFormat(flags.Arg(0))
+	} else {
+		input, err = io.ReadAll(stdin)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error reading input: %v\n", err)
+		return 1
+	}
+
+	if *from != "" {
+		if inFormat, err = buildtree.LookupFormat(*from); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	root, err := buildtree.Parse(context.Background(), string(input), buildtree.ParseOptions{Format: inFormat, Raw: true})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error parsing input: %v\n", err)
+		return 1
+	}
+
+	if err := buildtree.Render(stdout, root, buildtree.RenderOptions{Format: outFormat, Comments: true}); err != nil {
+		fmt.Fprintf(stderr, "Error writing output: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func printConvertHelp(w io.Writer) {
+	names := make([]string, len(buildtree.Formats))
+	for i, f := range buildtree.Formats {
+		names[i] = string(f)
+	}
+
+	fmt.Fprintln(w, "Usage: buildtree convert [OPTIONS] --to FORMAT [FILE]")
+	fmt.Fprintln(w, "Convert a structure between formats (reads stdin without a file)")
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  --from FORMAT		Input format (default: from file extension, else tree)")
+	fmt.Fprintln(w, "  --to FORMAT		Output format")
+	fmt.Fprintln(w, "  -h, --help		Show this help message")
+	fmt.Fprintf(w, "Formats: %s\n", strings.Join(names, ", "))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunConvert_TreeToJSON(t *testing.T) {
+	input := "project/\n├── src/\n│   └── main.go\n└── LICENSE"
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	exitCode := run([]string{"convert", "--to", "json"}, strings.NewReader(input), stdout, stderr, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", exitCode, stderr.String())
+	}
+	for _, want := range []string{`"name": "project"`, `"name": "LICENSE"`, `"type": "file"`} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected output to contain %s, got:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestRunConvert_DetectFromExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(path, []byte("name: app\nchildren:\n  - name: main.go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+	exitCode := run([]string{"convert", "--to", "tree", path}, &bytes.Buffer{}, stdout, &bytes.Buffer{}, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d", exitCode)
+	}
+	if expected := "app/\n└── main.go\n"; stdout.String() != expected {
+		t.Errorf("Expected output:\n%s\nGot:\n%s", expected, stdout.String())
+	}
+}
+
+func TestRunConvert_Errors(t *testing.T) {
+	tests := [][]string{
+		{"convert"},
+		{"convert", "--to", "xml"},
+		{"convert", "--from", "xml", "--to", "json"},
+		{"convert", "--from", "json", "--to", "tree"},
+	}
+
+	for _, args := range tests {
+		stderr := &bytes.Buffer{}
+		exitCode := run(args, strings.NewReader("project/"), &bytes.Buffer{}, stderr, &mockParser{}, &mockBuilder{})
+		if exitCode != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", args, exitCode)
+		}
+		if !strings.Contains(stderr.String(), "Error") {
+			t.Errorf("%v: error message was not printed", args)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/neomen/buildtree/internal/diff"
+)
+
+// Exit codes of the diff command, following diff(1)
+const (
+	diffSame    = 0
+	diffChanged = 1
+	diffTrouble = 2
+)
+
+// diffReport is the JSON output of the diff command
+type diffReport struct {
+	Match   bool          `json:"match"`
+	Changes []diff.Change `json:"changes"`
+}
+
+// runDiff compares a structure spec with an existing directory
+func runDiff(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p parserInterface, b builderInterface) int {
+	flags := flag.NewFlagSet("buildtree diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var ignore stringList
+	helpFlag := flags.Bool("help", false, "Show help")
+	jsonFlag := flags.Bool("json", false, "Print the differences as JSON")
+	colorMode := flags.String("color", "auto", "Colorize output: auto, always or never")
+	flags.Var(&ignore, "ignore", "Glob pattern of paths on disk to ignore (repeatable)")
+	flags.BoolVar(helpFlag, "h", false, "Alias for --help")
+
+	if err := flags.Parse(args); err != nil {
+		return diffTrouble
+	}
+
+	if *helpFlag {
+		printDiffHelp(stdout)
+		return diffSame
+	}
+
+	if flags.NArg() < 1 {
+		printDiffHelp(stderr)
+		fmt.Fprintln(stderr, "Error: No spec file provided")
+		return diffTrouble
+	}
+
+	color, err := useColor(stdout, *colorMode)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return diffTrouble
+	}
+
+	spec, err := readSpec(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "Error parsing spec: %v\n", err)
+		return diffTrouble
+	}
+
+	dir := spec.Name
+	if flags.NArg() > 1 {
+		dir = flags.Arg(1)
+	}
+
+	changes, err := diff.Compare(spec, dir, diff.Options{Ignore: ignore})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error comparing: %v\n", err)
+		return diffTrouble
+	}
+
+	if *jsonFlag {
+		report := diffReport{Match: len(changes) == 0, Changes: changes}
+		if report.Changes == nil {
+			report.Changes = []diff.Change{}
+		}
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(stderr, "Error writing output: %v\n", err)
+			return diffTrouble
+		}
+	} else if len(changes) > 0 {
+		if err := diff.Render(stdout, spec.Name, changes, color); err != nil {
+			fmt.Fprintf(stderr, "Error writing output: %v\n", err)
+			return diffTrouble
+		}
+	}
+
+	if len(changes) > 0 {
+		return diffChanged
+	}
+	return diffSame
+}
+
+func printDiffHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: buildtree diff [OPTIONS] SPEC [DIR]")
+	fmt.Fprintln(w, "Compare a structure spec with an existing directory (default: the spec's root name)")
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  --json			Print the differences as JSON")
+	fmt.Fprintln(w, "  --color MODE		Colorize output: auto, always or never (default:auto)")
+	fmt.Fprintln(w, "  --ignore PATTERN	Ignore paths on disk matching the glob (repeatable)")
+	fmt.Fprintln(w, "  -h, --help		Show this help message")
+	fmt.Fprintln(w, "Exit status is 0 if the directory matches, 1 if it differs and 2 on errors.")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDiffFixture(t *testing.T) (spec, dir string) {
+	t.Helper()
+	base := t.TempDir()
+	spec = filepath.Join(base, "spec.tree")
+	dir = filepath.Join(base, "project")
+
+	if err := os.WriteFile(spec, []byte("project/\n├── src/\n│   └── main.go\n└── go.mod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "src", "main.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return spec, dir
+}
+
+func TestRunDiff_Match(t *testing.T) {
+	spec, dir := writeDiffFixture(t)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+	exitCode := run([]string{"diff", spec, dir}, &bytes.Buffer{}, stdout, &bytes.Buffer{}, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d", exitCode)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no output, got %q", stdout.String())
+	}
+}
+
+func TestRunDiff_Differences(t *testing.T) {
+	spec, dir := writeDiffFixture(t)
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+	exitCode := run([]string{"diff", "--color", "never", spec, dir}, &bytes.Buffer{}, stdout, &bytes.Buffer{}, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	for _, want := range []string{"- ├── go.mod", "+ └── README.md"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestRunDiff_JSON(t *testing.T) {
+	spec, dir := writeDiffFixture(t)
+
+	stdout := &bytes.Buffer{}
+	exitCode := run([]string{"diff", "--json", spec, dir}, &bytes.Buffer{}, stdout, &bytes.Buffer{}, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+
+	var report diffReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if report.Match || len(report.Changes) != 1 || report.Changes[0].Path != "go.mod" {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
+
+func TestRunDiff_Errors(t *testing.T) {
+	spec, _ := writeDiffFixture(t)
+
+	tests := [][]string{
+		{"diff"},
+		{"diff", filepath.Join(t.TempDir(), "missing.tree")},
+		{"diff", spec, filepath.Join(t.TempDir(), "missing")},
+		{"diff", "--color", "sometimes", spec},
+	}
+
+	for _, args := range tests {
+		stderr := &bytes.Buffer{}
+		exitCode := run(args, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &mockParser{}, &mockBuilder{})
+		if exitCode != 2 {
+			t.Errorf("%v: expected exit code 2, got %d", args, exitCode)
+		}
+		if !strings.Contains(stderr.String(), "Error") {
+			t.Errorf("%v: error message was not printed", args)
+		}
+	}
+}
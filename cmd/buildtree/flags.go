@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// optionalString is a string flag whose value may be omitted, as in
+// --keep-empty or --keep-empty=.keep; a bare flag sets the fallback
+type optionalString struct {
+	value    string
+	fallback string
+}
+
+func (o *optionalString) String() string {
+	if o == nil {
+		return ""
+	}
+	return o.value
+}
+
+func (o *optionalString) Set(value string) error {
+	switch value {
+	case "true":
+		value = o.fallback
+	case "false":
+		value = ""
+	}
+	o.value = value
+	return nil
+}
+
+// IsBoolFlag lets the flag package accept the flag without a value
+func (o *optionalString) IsBoolFlag() bool {
+	return true
+}
+
+// parseInterspersed parses flags that may appear after positional
+// arguments and returns the positional arguments in order
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
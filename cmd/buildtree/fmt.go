@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// runFmt rewrites tree diagrams in the canonical style
+func runFmt(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p parserInterface, b builderInterface) int {
+	flags := flag.NewFlagSet("buildtree fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var opts buildtree.RenderOptions
+	var style string
+	helpFlag := flags.Bool("help", false, "Show help")
+	write := flags.Bool("w", false, "Write the result back to the source file")
+	list := flags.Bool("l", false, "List files whose formatting differs")
+	check := flags.Bool("check", false, "Fail if any file is not formatted")
+	stripComments := flags.Bool("strip-comments", false, "Drop comments from the output")
+	flags.StringVar(&style, "style", string(buildtree.StyleUnicode), "Glyph set: unicode or ascii")
+	flags.IntVar(&opts.Indent, "indent", buildtree.DefaultIndent, "Columns per nesting level")
+	flags.BoolVar(&opts.Sort, "sort", false, "Order directories first, then by name")
+	flags.BoolVar(helpFlag, "h", false, "Alias for --help")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if *helpFlag {
+		printFmtHelp(stdout)
+		return 0
+	}
+
+	opts.Style = buildtree.Style(style)
+	opts.Comments = !*stripComments
+
+	// Without files, format stdin to stdout
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "Error: -w requires at least one file")
+			return 1
+		}
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error reading input: %v\n", err)
+			return 1
+		}
+		formatted, err := formatTree("<standard input>", string(input), p, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error formatting input: %v\n", err)
+			return 1
+		}
+		if *list || *check {
+			if formatted != string(input) {
+				fmt.Fprintln(stdout, "<standard input>")
+				if *check {
+					return 1
+				}
+			}
+			return 0
+		}
+		fmt.Fprint(stdout, formatted)
+		return 0
+	}
+
+	exitCode := 0
+	for _, path := range flags.Args() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error reading file: %v\n", err)
+			exitCode = 1
+			continue
+		}
+
+		formatted, err := formatTree(path, string(content), p, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error formatting %s: %v\n", path, err)
+			exitCode = 1
+			continue
+		}
+
+		changed := formatted != string(content)
+		if changed && (*list || *check) {
+			fmt.Fprintln(stdout, path)
+			if *check {
+				exitCode = 1
+			}
+		}
+		if changed && *write {
+			if err := os.WriteFile(path, []byte(formatted), 0644); err != nil {
+				fmt.Fprintf(stderr, "Error writing file: %v\n", err)
+				exitCode = 1
+			}
+		}
+		if !*write && !*list && !*check {
+			fmt.Fprint(stdout, formatted)
+		}
+	}
+
+	return exitCode
+}
+
+// formatTree parses a diagram and renders it back in the requested style.
+// Invalid names are reported against source.
+func formatTree(source, input string, p parserInterface, opts buildtree.RenderOptions) (string, error) {
+	root, err := p.ParseInput(input)
+	if err != nil {
+		return "", err
+	}
+	if err := checkNames(source, root); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := buildtree.Render(&buf, root, opts); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func printFmtHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: buildtree fmt [OPTIONS] [FILE...]")
+	fmt.Fprintln(w, "Rewrite tree diagrams in a canonical style (stdin to stdout without files)")
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  -w			Write the result back to the source file")
+	fmt.Fprintln(w, "  -l			List files whose formatting differs")
+	fmt.Fprintln(w, "  --check		Like -l, but exit with status 1 if any file differs")
+	fmt.Fprintln(w, "  --style STYLE		Glyph set: unicode or ascii (default:unicode)")
+	fmt.Fprintln(w, "  --indent N		Columns per nesting level (default:4)")
+	fmt.Fprintln(w, "  --sort			Order directories first, then by name")
+	fmt.Fprintln(w, "  --strip-comments	Drop comments from the output")
+	fmt.Fprintln(w, "  -h, --help		Show this help message")
+}
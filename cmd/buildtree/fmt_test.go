@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const unformattedTree = `project/ # demo
+|-- src/
+|   |-- main.go
+'-- go.mod`
+
+const formattedTree = `project/ # demo
+├── src/
+│   └── main.go
+└── go.mod
+`
+
+func TestRunFmt_Stdin(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	exitCode := run([]string{"fmt"}, strings.NewReader(unformattedTree), stdout, stderr, &realParser{}, &mockBuilder{})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", exitCode, stderr.String())
+	}
+	if stdout.String() != formattedTree {
+		t.Errorf("Expected output:\n%s\nGot:\n%s", formattedTree, stdout.String())
+	}
+}
+
+func TestRunFmt_Write(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.tree")
+	if err := os.WriteFile(path, []byte(unformattedTree), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	exitCode := run([]string{"fmt", "-w", path}, &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}, &realParser{}, &mockBuilder{})
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d", exitCode)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != formattedTree {
+		t.Errorf("Expected file content:\n%s\nGot:\n%s", formattedTree, content)
+	}
+}
+
+func TestRunFmt_Check(t *testing.T) {
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "clean.tree")
+	dirty := filepath.Join(dir, "dirty.tree")
+	if err := os.WriteFile(clean, []byte(formattedTree), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dirty, []byte(unformattedTree), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+	exitCode := run([]string{"fmt", "--check", clean, dirty}, &bytes.Buffer{}, stdout, &bytes.Buffer{}, &realParser{}, &mockBuilder{})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if stdout.String() != dirty+"\n" {
+		t.Errorf("Expected only %s to be listed, got %q", dirty, stdout.String())
+	}
+
+	// -l lists without failing
+	stdout.Reset()
+	exitCode = run([]string{"fmt", "-l", clean}, &bytes.Buffer{}, stdout, &bytes.Buffer{}, &realParser{}, &mockBuilder{})
+	if exitCode != 0 || stdout.Len() != 0 {
+		t.Errorf("Expected clean file to pass silently, got exit %d and %q", exitCode, stdout.String())
+	}
+}
+
+func TestRunFmt_Blocks(t *testing.T) {
+	spec := `app/
+├── @if docker
+│   ├── Dockerfile
+│   └── deploy/
+│       └── compose.yaml
+├── @each svc in services
+│   └── {{ .svc }}/
+│       └── main.go
+└── go.mod
+`
+	path := filepath.Join(t.TempDir(), "spec.tree")
+	if err := os.WriteFile(path, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := &bytes.Buffer{}
+	if code := run([]string{"fmt", "-w", path}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &mockBuilder{}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != spec {
+		t.Errorf("Block bodies not preserved.\nExpected:\n%s\nGot:\n%s", spec, content)
+	}
+}
+
+func TestRunFmt_InvalidNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.tree")
+	input := "bad/\n|-- a<b.txt\n'-- ok.txt\n"
+	if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"fmt", "-w", path}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &mockBuilder{})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if expected := path + ":2: invalid name: forbidden character '<' at position 2"; !strings.Contains(stderr.String(), expected) {
+		t.Errorf("Expected %q in stderr, got %q", expected, stderr.String())
+	}
+	if content, _ := os.ReadFile(path); string(content) != input {
+		t.Errorf("Expected the file to be left as is, got:\n%s", content)
+	}
+}
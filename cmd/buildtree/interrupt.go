@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// interruptFlags control how a build stops on Ctrl-C or a timeout
+type interruptFlags struct {
+	timeout  time.Duration
+	rollback bool
+}
+
+func newInterruptFlags(flags *flag.FlagSet) *interruptFlags {
+	i := &interruptFlags{}
+	flags.DurationVar(&i.timeout, "timeout", 0, "Stop the build after DURATION (0 = no limit)")
+	flags.BoolVar(&i.rollback, "rollback", false, "Remove the created paths if the build is interrupted")
+	return i
+}
+
+func printInterruptHelp(w io.Writer) {
+	fmt.Fprintln(w, "  --timeout DURATION	Stop the build after DURATION, e.g. 30s (default: no limit)")
+	fmt.Fprintln(w, "  --rollback		Remove created paths if the build is interrupted or times out")
+}
+
+// context returns a context that is canceled by SIGINT or when the
+// timeout expires
+func (i *interruptFlags) context(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt)
+	if i.timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, i.timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// interrupted reports whether a build stopped because its context ended
+func interrupted(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// reportInterrupted tells how far an interrupted build got and rolls it
+// back if requested. total is the number of paths in the spec, or 0 if
+// unknown. It returns whether the created paths were removed.
+func reportInterrupted(stderr io.Writer, err error, done, total int, events []buildtree.Event, i *interruptFlags) bool {
+	reason := "interrupted"
+	if errors.Is(err, context.DeadlineExceeded) {
+		reason = fmt.Sprintf("timed out (%s)", i.timeout)
+	}
+	if total > 0 {
+		fmt.Fprintf(stderr, "Build %s after %d of %d paths\n", reason, done, total)
+	} else {
+		fmt.Fprintf(stderr, "Build %s after %d paths\n", reason, done)
+	}
+
+	if !i.rollback {
+		return false
+	}
+	removed, rollbackErr := buildtree.Rollback(events)
+	if rollbackErr != nil {
+		fmt.Fprintf(stderr, "Error rolling back: %v\n", rollbackErr)
+		return false
+	}
+	fmt.Fprintf(stderr, "Rolled back %d created paths\n", removed)
+	return true
+}
+
+// countPaths returns the number of paths in the tree
+func countPaths(root *buildtree.Node) int {
+	n := 0
+	root.Walk(func(*buildtree.Node, int) error {
+		n++
+		return nil
+	}, nil)
+	return n
+}
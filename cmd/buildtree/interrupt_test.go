@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+func TestBuildTree_Interrupted(t *testing.T) {
+	tests := []struct {
+		rollback bool
+		report   string
+		code     int
+		stderr   []string
+		exists   bool
+	}{
+		{false, "", 130, []string{"Build interrupted after 2 of 5 paths\n"}, true},
+		{true, "", 130, []string{"Build interrupted after 2 of 5 paths\n", "Rolled back 2 created paths\n"}, false},
+		{true, "json", 130, []string{"Rolled back 2 created paths\n"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Chdir(t.TempDir())
+
+		// Creates two paths, then stops as if Ctrl-C was pressed
+		build := func(opts buildtree.BuildOptions) (*buildtree.Report, error) {
+			report := &buildtree.Report{Root: "app"}
+			for _, event := range []buildtree.Event{
+				{Kind: buildtree.Created, Path: "app", IsDir: true},
+				{Kind: buildtree.Created, Path: "app/a.txt"},
+			} {
+				if event.IsDir {
+					os.Mkdir(event.Path, 0755)
+				} else {
+					os.WriteFile(filepath.FromSlash(event.Path), nil, 0644)
+				}
+				report.Events = append(report.Events, event)
+				opts.Observer(event)
+			}
+			return report, context.Canceled
+		}
+
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		stop := &interruptFlags{rollback: tt.rollback}
+		if code := buildTree(stdout, stderr, build, buildtree.BuildOptions{}, tt.report, stop, 5); code != tt.code {
+			t.Errorf("%+v: expected exit code %d, got %d", tt, tt.code, code)
+		}
+		for _, s := range tt.stderr {
+			if !strings.Contains(stderr.String(), s) {
+				t.Errorf("%+v: expected %q in stderr, got %q", tt, s, stderr.String())
+			}
+		}
+		if _, err := os.Stat("app"); (err == nil) != tt.exists {
+			t.Errorf("%+v: expected app to exist: %v, got error %v", tt, tt.exists, err)
+		}
+
+		if tt.report == "json" {
+			var report buildReport
+			if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+				t.Fatalf("Invalid report: %v", err)
+			}
+			if report.Success || !report.Interrupted || !report.RolledBack || len(report.Paths) != 2 {
+				t.Errorf("Unexpected report %+v", report)
+			}
+		}
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	stderr := &bytes.Buffer{}
+	args := []string{"--timeout", "1ns", "app/\n└── main.go"}
+	if code := run(args, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &realBuilder{}); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "timed out (1ns)") {
+		t.Errorf("Expected a timeout message, got %q", stderr.String())
+	}
+	if _, err := os.Stat("app"); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be built, got %v", err)
+	}
+}
@@ -1,13 +1,14 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 
-	"github.com/neomen/buildtree/internal/builder"
-	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/internal/expand"
+	"github.com/neomen/buildtree/pkg/buildtree"
 )
 
 var (
@@ -18,37 +19,68 @@ var (
 
 // Добавим интерфейсы для зависимостей, чтобы можно было мокировать их в тестах
 type parserInterface interface {
-	ParseInput(input string) (*parser.Node, error)
+	ParseInput(input string) (*buildtree.Node, error)
 }
 
 type builderInterface interface {
-	BuildTree(root *parser.Node, maxDepth int) error
+	BuildTree(ctx context.Context, root *buildtree.Node, opts buildtree.BuildOptions) (*buildtree.Report, error)
+	BuildStream(ctx context.Context, r io.Reader, opts buildtree.BuildOptions) (*buildtree.Report, error)
 }
 
 // Реальные реализации
 type realParser struct{}
 type realBuilder struct{}
 
-func (r *realParser) ParseInput(input string) (*parser.Node, error) {
-	return parser.ParseInput(input)
+func (r *realParser) ParseInput(input string) (*buildtree.Node, error) {
+	return buildtree.Parse(context.Background(), input, buildtree.ParseOptions{Raw: true})
 }
 
-func (r *realBuilder) BuildTree(root *parser.Node, maxDepth int) error {
-	return builder.BuildTree(root, maxDepth)
+func (r *realBuilder) BuildTree(ctx context.Context, root *buildtree.Node, opts buildtree.BuildOptions) (*buildtree.Report, error) {
+	return buildtree.Build(ctx, root, opts)
+}
+
+func (r *realBuilder) BuildStream(ctx context.Context, input io.Reader, opts buildtree.BuildOptions) (*buildtree.Report, error) {
+	return buildtree.BuildStream(ctx, input, opts)
+}
+
+// command is the entry point of a subcommand
+type command func(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p parserInterface, b builderInterface) int
+
+// commands maps subcommand names to their implementations
+var commands = map[string]command{
+	"scan":     runScan,
+	"fmt":      runFmt,
+	"convert":  runConvert,
+	"diff":     runDiff,
+	"verify":   runVerify,
+	"sync":     runSync,
+	"template": runTemplate,
+	"new":      runNew,
+	"build":    runBuild,
 }
 
 // Вынесем основную логику в отдельную функцию для тестирования
 func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p parserInterface, b builderInterface) int {
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			return cmd(args[1:], stdin, stdout, stderr, p, b)
+		}
+	}
+
 	// Создаем новый набор флагов для каждого вызова
 	flags := flag.NewFlagSet("buildtree", flag.ContinueOnError)
 	flags.SetOutput(stderr) // Устанавливаем вывод ошибок флагов в stderr
 
 	filePath := flags.String("input-file", "", "Path to file containing directory structure")
 	helpFlag := flags.Bool("help", false, "Show help")
-	maxDepth := flags.Int("max-depth", 20, "Maximum nesting depth allowed (0 = no limit)")
 	versionFlag := flags.Bool("version", false, "Show version information")
+	valuesFile := flags.String("values", "", "YAML file with template values")
+	dryRun := flags.Bool("dry-run", false, "Print the expanded structure without creating it")
+	stream := flags.Bool("stream", false, "Build while reading the input, in bounded memory")
+	bf := newBuildFlags(flags)
+	var assignments stringList
+	flags.Var(&assignments, "set", "Set a template value as key=value (repeatable)")
 	flags.StringVar(filePath, "i", "", "Alias for --input-file")
-	flags.IntVar(maxDepth, "d", 20, "Alias for --max-depth")
 	flags.BoolVar(versionFlag, "v", false, "Alias for --version")
 	flags.BoolVar(helpFlag, "h", false, "Alias for --help")
 
@@ -67,6 +99,22 @@ func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p p
 		return 0
 	}
 
+	if err := bf.check(); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	ctx := context.Background()
+	if *stream {
+		opts, err := bf.options()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error locating skeletons: %v\n", err)
+			return 1
+		}
+		ctx, cancel := bf.stop.context(ctx)
+		defer cancel()
+		return runStream(ctx, stdin, stdout, stderr, flags, b, *filePath, opts, bf.reportFormat, bf.stop, bf.repo)
+	}
+
 	input := getInput(*filePath, stdin, flags, stderr)
 	if input == "" {
 		return 1
@@ -79,13 +127,39 @@ func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p p
 		return 1
 	}
 
-	// Build the file structure
-	if err := b.BuildTree(root, *maxDepth); err != nil {
-		fmt.Fprintf(stderr, "Error building tree: %v\n", err)
+	// Splice in @include specs and substitute template variables
+	values, err := templateValues(*valuesFile, assignments)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error reading values: %v\n", err)
+		return 1
+	}
+	root, err = buildtree.Resolve(ctx, root, buildtree.ParseOptions{File: *filePath, Values: values})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error resolving spec: %v\n", err)
 		return 1
 	}
+	return bf.build(stdout, stderr, b, root, *dryRun)
+}
+
+// templateValues merges values from the environment, a values file and
+// --set assignments, in increasing order of precedence
+func templateValues(valuesFile string, assignments []string) (expand.Values, error) {
+	values := expand.FromEnv(os.Environ())
+
+	if valuesFile != "" {
+		fileValues, err := expand.LoadFile(valuesFile)
+		if err != nil {
+			return nil, err
+		}
+		values.Merge(fileValues)
+	}
 
-	return 0
+	for _, assignment := range assignments {
+		if err := values.Set(assignment); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
 }
 
 func getInput(filePath string, stdin io.Reader, flags *flag.FlagSet, stderr io.Writer) string {
@@ -110,9 +184,25 @@ func getInput(filePath string, stdin io.Reader, flags *flag.FlagSet, stderr io.W
 func printHelp(w io.Writer) {
 	fmt.Fprintln(w, "Buildtree - Instant Directory Tree Builder")
 	fmt.Fprintln(w, "Usage: buildtree [OPTIONS] \"DIRECTORY_STRUCTURE\"")
+	fmt.Fprintln(w, "       buildtree COMMAND [OPTIONS] [ARGS]")
+	fmt.Fprintln(w, "Commands:")
+	fmt.Fprintln(w, "  scan [DIR]		Print an existing directory as a tree diagram")
+	fmt.Fprintln(w, "  fmt [FILE...]		Rewrite tree diagrams in a canonical style")
+	fmt.Fprintln(w, "  convert --to FORMAT	Convert a structure between formats")
+	fmt.Fprintln(w, "  diff SPEC [DIR]	Compare a structure spec with an existing directory")
+	fmt.Fprintln(w, "  verify SPEC [DIR]	Fail if a directory does not follow a structure spec")
+	fmt.Fprintln(w, "  sync SPEC [DIR]	Make a directory match a structure spec")
+	fmt.Fprintln(w, "  template COMMAND	Add, list, show or remove stored templates")
+	fmt.Fprintln(w, "  new TEMPLATE NAME	Build a project from a stored template")
+	fmt.Fprintln(w, "  build BASE OVERLAY...	Merge specs and build the result")
 	fmt.Fprintln(w, "Options:")
 	fmt.Fprintln(w, "  -i, --input-file FILE	Read structure from file")
 	fmt.Fprintln(w, "  -d, --max-depth N	Maximum nesting depth allowed (0=unlimited, default:20)")
+	fmt.Fprintln(w, "  --set KEY=VALUE	Set a template value (repeatable)")
+	fmt.Fprintln(w, "  --values FILE		Read template values from a YAML file")
+	fmt.Fprintln(w, "  --dry-run		Print the expanded structure without creating it")
+	fmt.Fprintln(w, "  --stream		Build while reading the input (file, argument or stdin)")
+	printBuildFlagsHelp(w)
 	fmt.Fprintln(w, "  -h, --help		Show this help message")
 	fmt.Fprintln(w, "  -v, --version		Show version information")
 	fmt.Fprintln(w, "\nExamples:")
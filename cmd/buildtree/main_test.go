@@ -2,29 +2,43 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 
-	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/internal/gitrepo"
+	"github.com/neomen/buildtree/internal/skeleton"
+	"github.com/neomen/buildtree/pkg/buildtree"
+	"github.com/neomen/buildtree/pkg/tree"
 )
 
 // Mock implementations for testing
 type mockParser struct {
-	parseFunc func(input string) (*parser.Node, error)
+	parseFunc func(input string) (*tree.Node, error)
 }
 
-func (m *mockParser) ParseInput(input string) (*parser.Node, error) {
+func (m *mockParser) ParseInput(input string) (*tree.Node, error) {
 	return m.parseFunc(input)
 }
 
 type mockBuilder struct {
-	buildFunc func(root *parser.Node, maxDepth int) error
+	buildFunc func(root *tree.Node, opts buildtree.BuildOptions) error
 }
 
-func (m *mockBuilder) BuildTree(root *parser.Node, maxDepth int) error {
-	return m.buildFunc(root, maxDepth)
+func (m *mockBuilder) BuildTree(ctx context.Context, root *tree.Node, opts buildtree.BuildOptions) (*buildtree.Report, error) {
+	return &buildtree.Report{}, m.buildFunc(root, opts)
+}
+
+func (m *mockBuilder) BuildStream(ctx context.Context, r io.Reader, opts buildtree.BuildOptions) (*buildtree.Report, error) {
+	_, err := io.Copy(io.Discard, r)
+	return &buildtree.Report{}, err
 }
 
 func TestRun_HelpFlag(t *testing.T) {
@@ -110,21 +124,21 @@ func TestRun_FileInput(t *testing.T) {
 
 	// Mock dependencies
 	p := &mockParser{
-		parseFunc: func(input string) (*parser.Node, error) {
+		parseFunc: func(input string) (*tree.Node, error) {
 			if input != content {
 				t.Errorf("Expected content %q, got %q", content, input)
 			}
-			return &parser.Node{Name: "project", IsDir: true}, nil
+			return &tree.Node{Name: "project", IsDir: true}, nil
 		},
 	}
 
 	b := &mockBuilder{
-		buildFunc: func(root *parser.Node, maxDepth int) error {
+		buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
 			if root.Name != "project" {
 				t.Errorf("Expected root name 'project', got %q", root.Name)
 			}
-			if maxDepth != 20 {
-				t.Errorf("Expected maxDepth 20, got %d", maxDepth)
+			if opts.MaxDepth != 20 {
+				t.Errorf("Expected maxDepth 20, got %d", opts.MaxDepth)
 			}
 			return nil
 		},
@@ -152,16 +166,16 @@ func TestRun_StdinInput(t *testing.T) {
 
 	// Mock dependencies
 	p := &mockParser{
-		parseFunc: func(actualInput string) (*parser.Node, error) {
+		parseFunc: func(actualInput string) (*tree.Node, error) {
 			if actualInput != input {
 				t.Errorf("Expected content %q, got %q", input, actualInput)
 			}
-			return &parser.Node{Name: "project", IsDir: true}, nil
+			return &tree.Node{Name: "project", IsDir: true}, nil
 		},
 	}
 
 	b := &mockBuilder{
-		buildFunc: func(root *parser.Node, maxDepth int) error {
+		buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
 			if root.Name != "project" {
 				t.Errorf("Expected root name 'project', got %q", root.Name)
 			}
@@ -184,13 +198,13 @@ func TestRun_StdinInput(t *testing.T) {
 func TestRun_ParseError(t *testing.T) {
 	// Mock dependencies with error
 	p := &mockParser{
-		parseFunc: func(input string) (*parser.Node, error) {
+		parseFunc: func(input string) (*tree.Node, error) {
 			return nil, errors.New("parse error")
 		},
 	}
 
 	b := &mockBuilder{
-		buildFunc: func(root *parser.Node, maxDepth int) error {
+		buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
 			return nil
 		},
 	}
@@ -214,13 +228,13 @@ func TestRun_ParseError(t *testing.T) {
 func TestRun_BuildError(t *testing.T) {
 	// Mock dependencies with error
 	p := &mockParser{
-		parseFunc: func(input string) (*parser.Node, error) {
-			return &parser.Node{Name: "project", IsDir: true}, nil
+		parseFunc: func(input string) (*tree.Node, error) {
+			return &tree.Node{Name: "project", IsDir: true}, nil
 		},
 	}
 
 	b := &mockBuilder{
-		buildFunc: func(root *parser.Node, maxDepth int) error {
+		buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
 			return errors.New("build error")
 		},
 	}
@@ -244,15 +258,15 @@ func TestRun_BuildError(t *testing.T) {
 func TestRun_MaxDepthFlag(t *testing.T) {
 	// Mock dependencies
 	p := &mockParser{
-		parseFunc: func(input string) (*parser.Node, error) {
-			return &parser.Node{Name: "project", IsDir: true}, nil
+		parseFunc: func(input string) (*tree.Node, error) {
+			return &tree.Node{Name: "project", IsDir: true}, nil
 		},
 	}
 
 	b := &mockBuilder{
-		buildFunc: func(root *parser.Node, maxDepth int) error {
-			if maxDepth != 5 {
-				t.Errorf("Expected maxDepth 5, got %d", maxDepth)
+		buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
+			if opts.MaxDepth != 5 {
+				t.Errorf("Expected maxDepth 5, got %d", opts.MaxDepth)
 			}
 			return nil
 		},
@@ -274,13 +288,13 @@ func TestRun_MaxDepthFlag(t *testing.T) {
 func TestMainFunctionWrapper(t *testing.T) {
 	// Mock dependencies
 	p := &mockParser{
-		parseFunc: func(input string) (*parser.Node, error) {
-			return &parser.Node{Name: "project", IsDir: true}, nil
+		parseFunc: func(input string) (*tree.Node, error) {
+			return &tree.Node{Name: "project", IsDir: true}, nil
 		},
 	}
 
 	b := &mockBuilder{
-		buildFunc: func(root *parser.Node, maxDepth int) error {
+		buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
 			return nil
 		},
 	}
@@ -300,3 +314,386 @@ func TestMainFunctionWrapper(t *testing.T) {
 		t.Error("Version information was not printed")
 	}
 }
+
+func TestRun_TemplateValues(t *testing.T) {
+	valuesFile := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(valuesFile, []byte("ServiceName: fromfile\nPort: \"80\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BUILDTREE_VAR_Owner", "team")
+
+	p := &mockParser{
+		parseFunc: func(input string) (*tree.Node, error) {
+			return &tree.Node{
+				Name:  "{{ .ServiceName }}",
+				IsDir: true,
+				Children: []*tree.Node{
+					{Name: "{{ .Owner }}-{{ .Port }}.txt", Level: 1},
+				},
+			}, nil
+		},
+	}
+
+	var built *tree.Node
+	b := &mockBuilder{
+		buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
+			built = root
+			return nil
+		},
+	}
+
+	args := []string{"--values", valuesFile, "--set", "ServiceName=billing", "spec"}
+	exitCode := run(args, &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}, p, b)
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d", exitCode)
+	}
+	if built.Name != "billing" {
+		t.Errorf("Expected --set to override the values file, got %q", built.Name)
+	}
+	if built.Children[0].Name != "team-80.txt" {
+		t.Errorf("Expected 'team-80.txt', got %q", built.Children[0].Name)
+	}
+}
+
+func TestRun_UndefinedVariables(t *testing.T) {
+	p := &mockParser{
+		parseFunc: func(input string) (*tree.Node, error) {
+			return &tree.Node{Name: "{{ .ServiceName }}", IsDir: true}, nil
+		},
+	}
+
+	b := &mockBuilder{
+		buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
+			t.Error("Nothing should be built with undefined variables")
+			return nil
+		},
+	}
+
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"spec"}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, p, b)
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "undefined variables: ServiceName") {
+		t.Errorf("Expected undefined variable error, got %q", stderr.String())
+	}
+}
+
+func TestRun_Includes(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "main.tree")
+	if err := os.WriteFile(spec, []byte("project/\n├── @include lint.tree\n└── main.go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lint.tree"), []byte("lint/\n└── .golangci.yml\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var built *tree.Node
+	b := &mockBuilder{
+		buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
+			built = root
+			return nil
+		},
+	}
+
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"-i", spec}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, b)
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", exitCode, stderr.String())
+	}
+	if len(built.Children) != 2 || built.Children[0].Name != ".golangci.yml" {
+		t.Errorf("Expected the included file spliced in, got %+v", built.Children)
+	}
+}
+
+func TestRun_IncludeError(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "main.tree")
+	if err := os.WriteFile(spec, []byte("project/\n└── @include missing.tree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"-i", spec}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &mockBuilder{})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), spec+":2: @include missing.tree") {
+		t.Errorf("Expected error citing the directive, got %q", stderr.String())
+	}
+}
+
+func TestRun_DryRunBlocks(t *testing.T) {
+	input := `app/
+├── @if docker
+│   └── Dockerfile
+└── cmd/
+    └── @each svc in services
+        └── {{ .svc }}/
+            └── main.go`
+
+	b := &mockBuilder{
+		buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
+			t.Error("Nothing should be built in a dry run")
+			return nil
+		},
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	args := []string{"--dry-run", "--set", "docker=false", "--set", "services=api,worker", input}
+	exitCode := run(args, &bytes.Buffer{}, stdout, stderr, &realParser{}, b)
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", exitCode, stderr.String())
+	}
+	expected := `app/
+└── cmd/
+    ├── api/
+    │   └── main.go
+    └── worker/
+        └── main.go
+`
+	if stdout.String() != expected {
+		t.Errorf("Unexpected dry run output.\nExpected:\n%s\nGot:\n%s", expected, stdout.String())
+	}
+}
+
+func TestRun_Skeletons(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		args []string
+		dirs []string
+	}{
+		{[]string{"app/\n└── main.go"}, nil},
+		{[]string{"--skeletons", "app/\n└── main.go"}, []string{"buildtree/skeletons"}},
+		{[]string{"--skeleton-dir", "mine", "app/\n└── main.go"}, []string{"mine", "buildtree/skeletons"}},
+	}
+
+	for _, tt := range tests {
+		var content buildtree.ContentProvider
+		b := &mockBuilder{
+			buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
+				content = opts.Content
+				return nil
+			},
+		}
+
+		if code := run(tt.args, &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}, &realParser{}, b); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d", tt.args, code)
+		}
+		if tt.dirs == nil {
+			if content != nil {
+				t.Errorf("%v: skeletons should be off by default", tt.args)
+			}
+			continue
+		}
+
+		provider, ok := content.(*skeleton.Provider)
+		if !ok || len(provider.Dirs) != len(tt.dirs) {
+			t.Fatalf("%v: unexpected provider %+v", tt.args, content)
+		}
+		for i, dir := range tt.dirs {
+			if !strings.HasSuffix(filepath.ToSlash(provider.Dirs[i]), dir) {
+				t.Errorf("%v: expected dir %d to end with %q, got %q", tt.args, i, dir, provider.Dirs[i])
+			}
+		}
+	}
+}
+
+func TestRun_KeepEmpty(t *testing.T) {
+	tests := []struct {
+		args        []string
+		placeholder string
+	}{
+		{[]string{"app/"}, ""},
+		{[]string{"--keep-empty", "app/"}, ".gitkeep"},
+		{[]string{"--keep-empty=.keep", "app/"}, ".keep"},
+		{[]string{"--keep-empty=README.md", "app/"}, "README.md"},
+	}
+
+	for _, tt := range tests {
+		var placeholder string
+		b := &mockBuilder{
+			buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
+				placeholder = opts.Placeholder
+				return nil
+			},
+		}
+
+		if code := run(tt.args, &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}, &realParser{}, b); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d", tt.args, code)
+		}
+		if placeholder != tt.placeholder {
+			t.Errorf("%v: expected placeholder %q, got %q", tt.args, tt.placeholder, placeholder)
+		}
+	}
+}
+
+func TestRun_GitInit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Chdir(t.TempDir())
+
+	stderr := &bytes.Buffer{}
+	args := []string{"--generate-gitignore", "--git-commit=Scaffold", "app/\n├── main.go\n└── docs/"}
+	if code := run(args, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &realBuilder{}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	out, err := exec.Command("git", "-C", "app", "log", "--format=%s", "--name-only").Output()
+	if err != nil {
+		t.Fatalf("git log: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "Scaffold\n\n.gitignore\nmain.go" {
+		t.Errorf("Unexpected history %q", got)
+	}
+}
+
+func TestRun_GitCommitCreatedOnly(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Chdir(t.TempDir())
+
+	// A file that was in the directory before the build
+	if err := os.MkdirAll("app", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("app", "secrets.env"), []byte("TOKEN=x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := &bytes.Buffer{}
+	args := []string{"-j", "4", "--keep-empty", "--git-commit", "app/\n├── main.go\n└── docs/"}
+	if code := run(args, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &realBuilder{}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	out, err := exec.Command("git", "-C", "app", "log", "--format=%s", "--name-only").Output()
+	if err != nil {
+		t.Fatalf("git log: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "Initial commit\n\ndocs/.gitkeep\nmain.go" {
+		t.Errorf("Unexpected history %q", got)
+	}
+}
+
+func TestRepoFlags_TrackOnlyForCommit(t *testing.T) {
+	r := &repoFlags{init: true}
+	if opts := r.track(buildtree.BuildOptions{}); opts.Observer != nil {
+		t.Error("Expected no observer without --git-commit")
+	}
+
+	r.commit.value = gitrepo.DefaultMessage
+	opts := r.track(buildtree.BuildOptions{})
+	opts.Observer(buildtree.Event{Kind: buildtree.Created, Path: "app/main.go"})
+	opts.Observer(buildtree.Event{Kind: buildtree.Existed, Path: "app/go.mod"})
+	if !reflect.DeepEqual(r.created, []string{"app/main.go"}) {
+		t.Errorf("Expected only the created file to be tracked, got %v", r.created)
+	}
+}
+
+func TestRun_GitInitWithoutGit(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("PATH", t.TempDir())
+
+	stderr := &bytes.Buffer{}
+	if code := run([]string{"--git-init", "app/"}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &realBuilder{}); code != 0 {
+		t.Errorf("Expected exit code 0 without git, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Warning: git executable not found in PATH") {
+		t.Errorf("Expected a warning, got %q", stderr.String())
+	}
+	if _, err := os.Stat("app"); err != nil {
+		t.Errorf("The tree should still be built: %v", err)
+	}
+}
+
+func TestRun_Jobs(t *testing.T) {
+	tests := []struct {
+		args []string
+		jobs int
+		code int
+	}{
+		{[]string{"app/"}, 1, 0},
+		{[]string{"-j", "8", "app/"}, 8, 0},
+		{[]string{"--jobs", "0", "app/"}, runtime.NumCPU(), 0},
+		{[]string{"--jobs", "-2", "app/"}, 0, 1},
+	}
+
+	for _, tt := range tests {
+		jobs := 0
+		b := &mockBuilder{
+			buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
+				jobs = opts.Jobs
+				return nil
+			},
+		}
+
+		if code := run(tt.args, &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}, &realParser{}, b); code != tt.code {
+			t.Fatalf("%v: expected exit code %d, got %d", tt.args, tt.code, code)
+		}
+		if jobs != tt.jobs {
+			t.Errorf("%v: expected %d jobs, got %d", tt.args, tt.jobs, jobs)
+		}
+	}
+}
+
+func TestRun_Rootless(t *testing.T) {
+	inputs := map[string]string{
+		"dot root":      ".\n├── src/\n│   └── main.go\n└── go.mod",
+		"indented":      "├── src/\n│   └── main.go\n└── go.mod",
+		"several roots": "src/\n└── main.go\ngo.mod",
+	}
+
+	for name, input := range inputs {
+		t.Chdir(t.TempDir())
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		if code := run([]string{"--report", "json", input}, &bytes.Buffer{}, stdout, stderr, &realParser{}, &realBuilder{}); code != 0 {
+			t.Fatalf("%s: expected exit code 0, got %d (stderr: %s)", name, code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), `"root": "."`) {
+			t.Errorf("%s: expected root '.' in report, got %s", name, stdout.String())
+		}
+		for _, path := range []string{"src/main.go", "go.mod"} {
+			if _, err := os.Stat(filepath.FromSlash(path)); err != nil {
+				t.Errorf("%s: expected %s to exist: %v", name, path, err)
+			}
+		}
+	}
+}
+
+func TestRun_DryRunInvalidNames(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	args := []string{"--dry-run", "app/\n├── CON\n└── src/\n    └── a:b.go"}
+	if code := run(args, &bytes.Buffer{}, stdout, stderr, &realParser{}, &realBuilder{}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	expected := "Skipping 'app/CON' - invalid name: reserved Windows name 'CON'\n" +
+		"Skipping 'app/src/a:b.go' - invalid name: forbidden character ':' at position 2\n"
+	if stderr.String() != expected {
+		t.Errorf("Expected stderr %q, got %q", expected, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "a:b.go") {
+		t.Errorf("Expected the structure on stdout, got %q", stdout.String())
+	}
+}
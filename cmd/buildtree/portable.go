@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// checkPortable reports sibling names that clash on case-insensitive or
+// normalizing file systems, as warnings or, with --portable, as errors
+func checkPortable(stderr io.Writer, root *buildtree.Node, portable bool) int {
+	collisions := buildtree.Collisions(root)
+	label := "Warning"
+	if portable {
+		label = "Error"
+	}
+	for _, c := range collisions {
+		fmt.Fprintf(stderr, "%s: %v\n", label, c)
+	}
+	if portable && len(collisions) > 0 {
+		return 1
+	}
+	return 0
+}
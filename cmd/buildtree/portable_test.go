@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun_Portable(t *testing.T) {
+	spec := "app/\n├── README.md\n├── readme.md\n└── go.mod"
+
+	tests := []struct {
+		args   []string
+		code   int
+		output string
+	}{
+		{[]string{"--dry-run"}, 0, "Warning: app/readme.md: case-insensitive collision with 'README.md' (line 3)\n"},
+		{[]string{"--dry-run", "--portable"}, 1, "Error: app/readme.md: case-insensitive collision with 'README.md' (line 3)\n"},
+		{[]string{"--dry-run", "--portable", "--exclude", "readme.md"}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		stderr := &bytes.Buffer{}
+		args := append(tt.args, spec)
+		if code := run(args, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &realBuilder{}); code != tt.code {
+			t.Fatalf("%v: expected exit code %d, got %d (stderr: %s)", tt.args, tt.code, code, stderr.String())
+		}
+		if stderr.String() != tt.output {
+			t.Errorf("%v: expected stderr %q, got %q", tt.args, tt.output, stderr.String())
+		}
+	}
+}
+
+func TestRunBuild_Portable(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeSpec(t, "base.tree", "app/\n└── Makefile\n")
+	writeSpec(t, "overlay.tree", "app/\n└── makefile\n")
+
+	stderr := &bytes.Buffer{}
+	args := []string{"build", "--portable", "base.tree", "overlay.tree"}
+	if code := run(args, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &realBuilder{}); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "app/makefile: case-insensitive collision with 'Makefile' (overlay.tree:2)") {
+		t.Errorf("Unexpected stderr %q", stderr.String())
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/neomen/buildtree/internal/gitrepo"
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// repoFlags are the git options applied to the root directory after a build
+type repoFlags struct {
+	init      bool
+	commit    optionalString
+	gitignore bool
+
+	created []string // Files created by the build, as reported
+}
+
+func newRepoFlags(flags *flag.FlagSet) *repoFlags {
+	r := &repoFlags{commit: optionalString{fallback: gitrepo.DefaultMessage}}
+	flags.BoolVar(&r.init, "git-init", false, "Initialize a git repository in the root directory")
+	flags.Var(&r.commit, "git-commit", "Commit the created files (implies --git-init)")
+	flags.BoolVar(&r.gitignore, "generate-gitignore", false, "Generate a .gitignore for the detected languages")
+	return r
+}
+
+func printRepoHelp(w io.Writer) {
+	fmt.Fprintln(w, "  --git-init		Initialize a git repository in the root directory")
+	fmt.Fprintln(w, "  --git-commit[=MSG]	Commit the created files (implies --git-init)")
+	fmt.Fprintln(w, "  --generate-gitignore	Generate a .gitignore for the languages in the tree")
+}
+
+// track records the files a build creates, so that --git-commit stages
+// only those and not files that were already in the directory. Without
+// --git-commit nothing is recorded.
+func (r *repoFlags) track(opts buildtree.BuildOptions) buildtree.BuildOptions {
+	if r.commit.value == "" {
+		return opts
+	}
+	observe := opts.Observer
+	opts.Observer = func(event buildtree.Event) {
+		if !event.IsDir && (event.Kind == buildtree.Created || event.Kind == buildtree.Placeholder) {
+			r.created = append(r.created, event.Path)
+		}
+		if observe != nil {
+			observe(event)
+		}
+	}
+	return opts
+}
+
+// setupRepo runs the requested git steps in dir, where root was built. A
+// missing git binary only produces a warning, since the tree is already built.
+func setupRepo(stderr io.Writer, dir string, root *buildtree.Node, r *repoFlags) int {
+	var paths []string
+	for _, path := range r.created {
+		rel, err := filepath.Rel(dir, filepath.FromSlash(path))
+		if err != nil {
+			fmt.Fprintf(stderr, "Error initializing git repository: %v\n", err)
+			return 1
+		}
+		paths = append(paths, rel)
+	}
+
+	if r.gitignore {
+		written, err := gitrepo.WriteGitIgnore(dir, root)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error writing .gitignore: %v\n", err)
+			return 1
+		}
+		if written {
+			paths = append(paths, ".gitignore")
+		}
+	}
+
+	if !r.init && r.commit.value == "" {
+		return 0
+	}
+	err := gitrepo.Init(dir, gitrepo.Options{Commit: r.commit.value != "", Message: r.commit.value, Paths: paths})
+	if errors.Is(err, gitrepo.ErrGitNotFound) {
+		fmt.Fprintf(stderr, "Warning: %v; skipping repository setup\n", err)
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error initializing git repository: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// buildReport is the JSON output of --report json
+type buildReport struct {
+	Root         string                      `json:"root"`
+	Success      bool                        `json:"success"`
+	Error        string                      `json:"error,omitempty"`
+	Counts       map[buildtree.EventKind]int `json:"counts"`
+	Paths        []buildtree.Event           `json:"paths"`
+	Placeholders []string                    `json:"placeholders"`
+	Interrupted  bool                        `json:"interrupted,omitempty"` // Canceled or timed out
+	RolledBack   bool                        `json:"rolled_back,omitempty"`
+}
+
+// checkReportFormat validates the value of --report
+func checkReportFormat(format string) error {
+	if format != "" && format != "json" {
+		return fmt.Errorf("unknown report format %q (want json)", format)
+	}
+	return nil
+}
+
+// buildFunc runs a build with the given options
+type buildFunc func(opts buildtree.BuildOptions) (*buildtree.Report, error)
+
+// buildTree runs the build and reports the outcome: skipped paths are
+// printed to stderr, or everything is written to stdout as JSON when
+// reportFormat is "json". An interrupted build reports how far it got out
+// of total paths (0 if unknown) and exits with 130 on SIGINT.
+func buildTree(stdout, stderr io.Writer, build buildFunc, opts buildtree.BuildOptions, reportFormat string, stop *interruptFlags, total int) int {
+	if reportFormat == "" {
+		observe := opts.Observer
+		opts.Observer = func(event buildtree.Event) {
+			switch event.Kind {
+			case buildtree.SkippedDepth, buildtree.SkippedInvalid:
+				fmt.Fprintf(stderr, "Skipping '%s' - %s\n", event.Path, event.Reason)
+			}
+			if observe != nil {
+				observe(event)
+			}
+		}
+	}
+
+	// Count the paths done, and keep their events for a rollback
+	done := 0
+	var events []buildtree.Event
+	observe := opts.Observer
+	opts.Observer = func(event buildtree.Event) {
+		if event.Kind != buildtree.Placeholder {
+			done++
+		}
+		if stop.rollback {
+			events = append(events, event)
+		}
+		if observe != nil {
+			observe(event)
+		}
+	}
+
+	report, err := build(opts)
+	rolledBack := false
+	if interrupted(err) {
+		rolledBack = reportInterrupted(stderr, err, done, total, events, stop)
+	}
+	if reportFormat == "json" {
+		if writeErr := writeBuildReport(stdout, report, err, rolledBack); writeErr != nil {
+			fmt.Fprintf(stderr, "Error writing report: %v\n", writeErr)
+			return 1
+		}
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return 130
+	case interrupted(err):
+		return 1
+	case err != nil:
+		fmt.Fprintf(stderr, "Error building tree: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func writeBuildReport(w io.Writer, report *buildtree.Report, buildErr error, rolledBack bool) error {
+	if report == nil {
+		report = &buildtree.Report{}
+	}
+
+	out := buildReport{
+		Root:         report.Root,
+		Success:      buildErr == nil,
+		Counts:       report.Counts(),
+		Paths:        []buildtree.Event{},
+		Placeholders: []string{},
+		Interrupted:  interrupted(buildErr),
+		RolledBack:   rolledBack,
+	}
+	if buildErr != nil {
+		out.Error = buildErr.Error()
+	}
+	for _, event := range report.Events {
+		if event.Kind == buildtree.Placeholder {
+			out.Placeholders = append(out.Placeholders, event.Path)
+		} else {
+			out.Paths = append(out.Paths, event)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
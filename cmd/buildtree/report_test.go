@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRun_ReportJSON(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	args := []string{"--report", "json", "--keep-empty", "app/\n├── logs/\n├── bad:name\n└── main.go"}
+	if code := run(args, &bytes.Buffer{}, stdout, stderr, &realParser{}, &realBuilder{}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected no warnings with a JSON report, got %q", stderr.String())
+	}
+
+	var report struct {
+		Root    string         `json:"root"`
+		Success bool           `json:"success"`
+		Counts  map[string]int `json:"counts"`
+		Paths   []struct {
+			Path       string `json:"path"`
+			Type       string `json:"type"`
+			Outcome    string `json:"outcome"`
+			Reason     string `json:"reason"`
+			Violations []struct {
+				Kind     string `json:"kind"`
+				Value    string `json:"value"`
+				Position int    `json:"position"`
+			} `json:"violations"`
+		} `json:"paths"`
+		Placeholders []string `json:"placeholders"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", stdout.String(), err)
+	}
+
+	if report.Root != "app" || !report.Success {
+		t.Errorf("Unexpected report header %+v", report)
+	}
+	if report.Counts["created"] != 3 || report.Counts["skipped-invalid"] != 1 || report.Counts["placeholder"] != 1 || report.Counts["error"] != 0 {
+		t.Errorf("Unexpected counts %v", report.Counts)
+	}
+	if len(report.Paths) != 4 || report.Paths[2].Path != "app/bad:name" || report.Paths[2].Outcome != "skipped-invalid" || report.Paths[2].Reason != "invalid name: forbidden character ':' at position 4" {
+		t.Errorf("Unexpected paths %+v", report.Paths)
+	}
+	if v := report.Paths[2].Violations; len(v) != 1 || v[0].Kind != "forbidden-char" || v[0].Value != ":" || v[0].Position != 4 {
+		t.Errorf("Unexpected violations %+v", v)
+	}
+	if len(report.Placeholders) != 1 || report.Placeholders[0] != "app/logs/.gitkeep" {
+		t.Errorf("Unexpected placeholders %v", report.Placeholders)
+	}
+}
+
+func TestRun_SkippedWarnings(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	args := []string{"-d", "1", "app/\n├── src/\n│   └── main.go\n└── bad:name"}
+	if code := run(args, &bytes.Buffer{}, stdout, stderr, &realParser{}, &realBuilder{}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+
+	expected := "Skipping 'app/src/main.go' - exceeds max depth (1)\nSkipping 'app/bad:name' - invalid name: forbidden character ':' at position 4\n"
+	if stderr.String() != expected {
+		t.Errorf("Expected warnings %q, got %q", expected, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no output, got %q", stdout.String())
+	}
+}
+
+func TestRun_UnknownReportFormat(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	if code := run([]string{"--report", "xml", "app/"}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &mockBuilder{}); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown report format "xml"`) {
+		t.Errorf("Unexpected error %q", stderr.String())
+	}
+}
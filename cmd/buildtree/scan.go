@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/neomen/buildtree/internal/scanner"
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// runScan renders an existing directory as a tree diagram
+func runScan(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p parserInterface, b builderInterface) int {
+	flags := flag.NewFlagSet("buildtree scan", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var opts scanner.Options
+	var ignore stringList
+	helpFlag := flags.Bool("help", false, "Show help")
+	flags.IntVar(&opts.MaxDepth, "max-depth", 0, "Maximum depth to descend (0 = no limit)")
+	flags.BoolVar(&opts.Hidden, "all", false, "Include hidden files and directories")
+	flags.BoolVar(&opts.GitIgnore, "gitignore", false, "Skip entries matched by .gitignore files")
+	flags.Var(&ignore, "ignore", "Glob pattern of entries to skip (repeatable)")
+	flags.IntVar(&opts.MaxDepth, "d", 0, "Alias for --max-depth")
+	flags.BoolVar(&opts.Hidden, "a", false, "Alias for --all")
+	flags.BoolVar(helpFlag, "h", false, "Alias for --help")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if *helpFlag {
+		printScanHelp(stdout)
+		return 0
+	}
+
+	dir := "."
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+	opts.Ignore = ignore
+
+	root, err := scanner.Scan(dir, opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error scanning directory: %v\n", err)
+		return 1
+	}
+
+	if err := buildtree.Render(stdout, root, buildtree.RenderOptions{}); err != nil {
+		fmt.Fprintf(stderr, "Error writing tree: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func printScanHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: buildtree scan [OPTIONS] [DIR]")
+	fmt.Fprintln(w, "Print an existing directory as a tree diagram")
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  -d, --max-depth N	Maximum depth to descend (0=unlimited, default:0)")
+	fmt.Fprintln(w, "  -a, --all		Include hidden files and directories")
+	fmt.Fprintln(w, "  --ignore PATTERN	Skip entries matching the glob (repeatable)")
+	fmt.Fprintln(w, "  --gitignore		Skip entries matched by .gitignore files")
+	fmt.Fprintln(w, "  -h, --help		Show this help message")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunScan(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "project", "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "project", "src", "main.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "project", "LICENSE"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	exitCode := run([]string{"scan", filepath.Join(dir, "project")}, &bytes.Buffer{}, stdout, stderr, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", exitCode, stderr.String())
+	}
+
+	expected := "project/\n├── LICENSE\n└── src/\n    └── main.go\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected output:\n%s\nGot:\n%s", expected, stdout.String())
+	}
+}
+
+func TestRunScan_MissingDir(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	exitCode := run([]string{"scan", filepath.Join(t.TempDir(), "missing")}, &bytes.Buffer{}, stdout, stderr, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "Error scanning directory") {
+		t.Error("Error message was not printed")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// selectFlags choose the part of the tree that is built and where its
+// root goes
+type selectFlags struct {
+	only    stringList
+	exclude stringList
+	rootAs  string
+	noRoot  bool
+}
+
+func newSelectFlags(flags *flag.FlagSet) *selectFlags {
+	s := &selectFlags{}
+	flags.Var(&s.only, "only", "Build only paths matching PATTERN, relative to the root (repeatable)")
+	flags.Var(&s.exclude, "exclude", "Skip paths matching PATTERN, relative to the root (repeatable)")
+	flags.StringVar(&s.rootAs, "root-as", "", "Rename the root directory to NAME")
+	flags.BoolVar(&s.noRoot, "no-root", false, "Build the root's contents in the current directory")
+	return s
+}
+
+func printSelectHelp(w io.Writer) {
+	fmt.Fprintln(w, "  --only PATTERN		Build only matching paths and their parents (repeatable, ** spans dirs)")
+	fmt.Fprintln(w, "  --exclude PATTERN	Skip matching paths and their contents (repeatable)")
+	fmt.Fprintln(w, "  --root-as NAME		Rename the root directory to NAME")
+	fmt.Fprintln(w, "  --no-root		Build the root's contents in the current directory")
+}
+
+// check rejects contradictory options
+func (s *selectFlags) check() error {
+	if s.noRoot && s.rootAs != "" {
+		return fmt.Errorf("--root-as and --no-root cannot be combined")
+	}
+	return nil
+}
+
+// apply filters the tree and renames its root
+func (s *selectFlags) apply(root *buildtree.Node) error {
+	if err := root.Filter(s.only, s.exclude); err != nil {
+		return err
+	}
+	if s.rootAs != "" {
+		return root.Rename(s.rootAs)
+	}
+	return nil
+}
+
+// dir returns the directory the root is built as
+func (s *selectFlags) dir(root *buildtree.Node) string {
+	if s.noRoot {
+		return "."
+	}
+	return root.Name
+}
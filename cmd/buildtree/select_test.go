@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_Select(t *testing.T) {
+	spec := "app/\n├── cmd/\n│   └── main.go\n├── docs/\n│   └── guide.md\n├── internal/\n│   └── util_test.go\n└── go.mod\n"
+
+	tests := []struct {
+		args   []string
+		code   int
+		output string
+	}{
+		{[]string{"--only", "cmd/**"}, 0, "app/\n└── cmd/\n    └── main.go\n"},
+		{[]string{"--only", "**/*.go", "--only", "go.mod"}, 0, "app/\n├── cmd/\n│   └── main.go\n├── internal/\n│   └── util_test.go\n└── go.mod\n"},
+		{[]string{"--exclude", "docs", "--exclude", "**/*_test.go"}, 0, "app/\n├── cmd/\n│   └── main.go\n├── internal/\n└── go.mod\n"},
+		{[]string{"--only", "cmd", "--root-as", "tool"}, 0, "tool/\n└── cmd/\n    └── main.go\n"},
+		{[]string{"--only", "[a-"}, 1, `Error selecting paths: invalid pattern "[a-"`},
+		{[]string{"--root-as", "a/b"}, 1, "Error selecting paths"},
+		{[]string{"--root-as", "tool", "--no-root"}, 1, "--root-as and --no-root cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		args := append(append([]string{"--dry-run"}, tt.args...), spec)
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		if code := run(args, &bytes.Buffer{}, stdout, stderr, &realParser{}, &realBuilder{}); code != tt.code {
+			t.Fatalf("%v: expected exit code %d, got %d (stderr: %s)", tt.args, tt.code, code, stderr.String())
+		}
+		if tt.code == 0 && stdout.String() != tt.output {
+			t.Errorf("%v: expected\n%s\ngot\n%s", tt.args, tt.output, stdout.String())
+		}
+		if tt.code != 0 && !strings.Contains(stderr.String(), tt.output) {
+			t.Errorf("%v: expected %q in stderr, got %q", tt.args, tt.output, stderr.String())
+		}
+	}
+}
+
+func TestRun_NoRoot(t *testing.T) {
+	spec := "app/\n├── cmd/\n│   └── main.go\n└── go.mod\n"
+
+	for _, extra := range [][]string{nil, {"--stream"}} {
+		t.Chdir(t.TempDir())
+		args := append([]string{"--no-root"}, extra...)
+		args = append(args, spec)
+		stderr := &bytes.Buffer{}
+		if code := run(args, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &realBuilder{}); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d (stderr: %s)", extra, code, stderr.String())
+		}
+		for _, path := range []string{"cmd/main.go", "go.mod"} {
+			if _, err := os.Stat(filepath.FromSlash(path)); err != nil {
+				t.Errorf("%v: expected %s to exist: %v", extra, path, err)
+			}
+		}
+		if _, err := os.Stat("app"); !os.IsNotExist(err) {
+			t.Errorf("%v: expected no root directory, got %v", extra, err)
+		}
+	}
+}
+
+func TestRun_SelectStreamConflict(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	args := []string{"--stream", "--only", "cmd", "app/\n└── cmd/\n"}
+	if code := run(args, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &realBuilder{}); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "--stream cannot be combined with --only") {
+		t.Errorf("Unexpected error: %q", stderr.String())
+	}
+}
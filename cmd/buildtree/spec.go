@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// readSpec parses a structure file, choosing the format from its extension.
+// Names that are not valid paths are reported like parse errors.
+func readSpec(path string) (*buildtree.Node, error) {
+	root, err := buildtree.ParseFile(context.Background(), path, buildtree.ParseOptions{Raw: true})
+	if err != nil {
+		return nil, err
+	}
+	if err := buildtree.ResolveIncludes(root, path); err != nil {
+		return nil, err
+	}
+	if err := checkNames(path, root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// checkNames returns one "spec:line: invalid name: ..." error per node
+// whose name is not a valid path, joined in tree order. The wildcards *
+// and ? are allowed, since specs also describe paths to match.
+func checkNames(source string, root *buildtree.Node) error {
+	var errs []error
+	root.Walk(func(node *buildtree.Node, depth int) error {
+		if node.Directive != "" || (depth == 0 && node.Name == buildtree.CurrentDir) {
+			return nil
+		}
+		var violations []buildtree.Violation
+		if err, ok := buildtree.ValidateName(node.Name).(*buildtree.NameError); ok {
+			for _, v := range err.Violations {
+				if v.Kind != buildtree.ForbiddenChar || (v.Value != "*" && v.Value != "?") {
+					violations = append(violations, v)
+				}
+			}
+		}
+		if len(violations) == 0 {
+			return nil
+		}
+
+		// Nodes spliced in by @include point at the file they came from
+		file := source
+		if node.File != "" {
+			file = node.File
+		}
+		err := &buildtree.NameError{Name: node.Name, Violations: violations}
+		if node.Line > 0 {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", file, node.Line, err))
+		} else {
+			errs = append(errs, fmt.Errorf("%s: '%s': %w", file, node.Name, err))
+		}
+		return nil
+	}, nil)
+	return errors.Join(errs...)
+}
+
+// printDryRun writes the structure that would be built, after includes,
+// @if / @each blocks and variables have been expanded. Paths the build
+// would skip for their names are listed on stderr with the reason.
+func printDryRun(stdout, stderr io.Writer, root *buildtree.Node) int {
+	if err := buildtree.Render(stdout, root, buildtree.RenderOptions{Comments: true}); err != nil {
+		fmt.Fprintf(stderr, "Error writing structure: %v\n", err)
+		return 1
+	}
+	root.Walk(func(node *buildtree.Node, depth int) error {
+		if depth == 0 || node.Directive != "" {
+			return nil
+		}
+		if err := buildtree.ValidateName(node.Name); err != nil {
+			fmt.Fprintf(stderr, "Skipping '%s' - %v\n", path.Clean(node.Path()), err)
+			return buildtree.SkipSubtree
+		}
+		return nil
+	}, nil)
+	return 0
+}
+
+// contentProvider returns the skeleton provider for empty files, or nil
+// when skeletons are off. Directories given with --skeleton-dir come
+// before the user's default skeleton directory.
+func contentProvider(enabled bool, dirs []string) (buildtree.ContentProvider, error) {
+	if !enabled && len(dirs) == 0 {
+		return nil, nil
+	}
+	return buildtree.Skeletons(dirs...)
+}
+
+// useColor resolves a --color mode for the writer. In "auto" mode color is
+// used only for terminals and when NO_COLOR is not set.
+func useColor(w io.Writer, mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		f, ok := w.(*os.File)
+		if !ok {
+			return false, nil
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return false, nil
+		}
+		return info.Mode()&os.ModeCharDevice != 0, nil
+	}
+	return false, fmt.Errorf("invalid color mode %q (want auto, always or never)", mode)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// streamConflicts are the flags that need the whole tree before building
+var streamConflicts = []string{"set", "values", "dry-run", "generate-gitignore", "only", "exclude", "root-as", "portable"}
+
+// runStream builds the structure while reading it, from the input file,
+// the argument, or stdin when neither is given. Includes, blocks and
+// template variables are not expanded in this mode.
+func runStream(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, flags *flag.FlagSet, b builderInterface, filePath string, opts buildtree.BuildOptions, reportFormat string, stop *interruptFlags, repo *repoFlags) int {
+	var conflicts []string
+	flags.Visit(func(f *flag.Flag) {
+		for _, name := range streamConflicts {
+			if f.Name == name {
+				conflicts = append(conflicts, "--"+name)
+			}
+		}
+	})
+	if len(conflicts) > 0 {
+		fmt.Fprintf(stderr, "Error: --stream cannot be combined with %s\n", strings.Join(conflicts, ", "))
+		return 1
+	}
+
+	input := stdin
+	switch {
+	case filePath != "":
+		f, err := os.Open(filePath)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error reading file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		input = f
+	case flags.NArg() > 0:
+		input = strings.NewReader(flags.Arg(0))
+	}
+
+	var rootName string
+	build := func(opts buildtree.BuildOptions) (*buildtree.Report, error) {
+		report, err := b.BuildStream(ctx, input, opts)
+		if report != nil {
+			rootName = report.Root
+		}
+		return report, err
+	}
+	if code := buildTree(stdout, stderr, build, repo.track(opts), reportFormat, stop, 0); code != 0 {
+		return code
+	}
+
+	return setupRepo(stderr, rootName, &buildtree.Node{Name: rootName, IsDir: true}, repo)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_Stream(t *testing.T) {
+	spec := "app/\n├── src/\n│   └── main.go\n└── docs/\n"
+
+	tests := []struct {
+		name  string
+		args  []string
+		stdin string
+	}{
+		{"argument", []string{"--stream", spec}, ""},
+		{"stdin", []string{"--stream"}, spec},
+		{"file", []string{"--stream", "-i", "spec.tree"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if err := os.WriteFile("spec.tree", []byte(spec), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			stderr := &bytes.Buffer{}
+			if code := run(tt.args, strings.NewReader(tt.stdin), &bytes.Buffer{}, stderr, &realParser{}, &realBuilder{}); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+			for _, path := range []string{"app/src/main.go", "app/docs"} {
+				if _, err := os.Stat(filepath.FromSlash(path)); err != nil {
+					t.Errorf("Expected %s to exist: %v", path, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_StreamReport(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	stdout := &bytes.Buffer{}
+	args := []string{"--stream", "--report", "json", "app/\n├── a.txt\n└── bad:name"}
+	if code := run(args, &bytes.Buffer{}, stdout, &bytes.Buffer{}, &realParser{}, &realBuilder{}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+
+	var report buildReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid report: %v\n%s", err, stdout.String())
+	}
+	if report.Root != "app" || !report.Success {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if report.Counts["created"] != 2 || report.Counts["skipped-invalid"] != 1 {
+		t.Errorf("Unexpected counts %v", report.Counts)
+	}
+}
+
+func TestRun_StreamConflicts(t *testing.T) {
+	tests := [][]string{
+		{"--stream", "--dry-run", "app/"},
+		{"--stream", "--set", "a=b", "app/"},
+		{"--stream", "--generate-gitignore", "app/"},
+	}
+
+	for _, args := range tests {
+		stderr := &bytes.Buffer{}
+		if code := run(args, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &realParser{}, &realBuilder{}); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", args, code)
+		}
+		if !strings.Contains(stderr.String(), "--stream cannot be combined with") {
+			t.Errorf("%v: unexpected error %q", args, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/neomen/buildtree/internal/diff"
+	"github.com/neomen/buildtree/internal/reconcile"
+)
+
+// runSync makes a directory match a structure spec
+func runSync(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p parserInterface, b builderInterface) int {
+	flags := flag.NewFlagSet("buildtree sync", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var protect, ignore stringList
+	helpFlag := flags.Bool("help", false, "Show help")
+	prune := flags.Bool("prune", false, "Remove paths that are not in the spec")
+	yes := flags.Bool("yes", false, "Apply removals without asking for confirmation")
+	dryRun := flags.Bool("dry-run", false, "Print the plan without changing anything")
+	noTrash := flags.Bool("no-trash", false, "Delete pruned paths instead of moving them to the trash")
+	trash := flags.String("trash", "", "Directory to move pruned paths into (default: under the user cache directory)")
+	flags.Var(&protect, "protect", "Glob pattern of paths that are never pruned (repeatable)")
+	flags.Var(&ignore, "ignore", "Glob pattern of paths on disk to leave alone (repeatable)")
+	flags.BoolVar(yes, "y", false, "Alias for --yes")
+	flags.BoolVar(helpFlag, "h", false, "Alias for --help")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if *helpFlag {
+		printSyncHelp(stdout)
+		return 0
+	}
+
+	if flags.NArg() < 1 {
+		printSyncHelp(stderr)
+		fmt.Fprintln(stderr, "Error: No spec file provided")
+		return 1
+	}
+
+	spec, err := readSpec(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "Error parsing spec: %v\n", err)
+		return 1
+	}
+
+	dir := spec.Name
+	if flags.NArg() > 1 {
+		dir = flags.Arg(1)
+	}
+
+	opts := reconcile.Options{
+		Prune:   *prune,
+		Protect: append(append([]string{}, reconcile.DefaultProtect...), protect...),
+		Ignore:  ignore,
+	}
+	steps, err := reconcile.Plan(spec, dir, opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error comparing: %v\n", err)
+		return 1
+	}
+
+	removals := 0
+	for _, step := range steps {
+		fmt.Fprintln(stdout, describeStep(step))
+		if step.Action == reconcile.Remove {
+			removals++
+		}
+	}
+
+	if *dryRun || len(steps) == 0 {
+		return 0
+	}
+
+	if removals > 0 && !*yes {
+		if !confirm(stdin, stdout, fmt.Sprintf("Remove %d path(s) from %s?", removals, dir)) {
+			fmt.Fprintln(stderr, "Aborted")
+			return 1
+		}
+	}
+
+	trashPath := ""
+	if !*noTrash {
+		trashPath = *trash
+		if trashPath == "" {
+			if trashPath, err = defaultTrash(dir); err != nil {
+				fmt.Fprintf(stderr, "Error finding the trash directory: %v\n", err)
+				return 1
+			}
+		}
+	}
+
+	if err := reconcile.Apply(dir, steps, trashPath); err != nil {
+		fmt.Fprintf(stderr, "Error syncing: %v\n", err)
+		return 1
+	}
+
+	if removals > 0 && trashPath != "" {
+		fmt.Fprintf(stdout, "Moved %d path(s) to %s\n", removals, trashPath)
+	}
+	return 0
+}
+
+// defaultTrash returns a new timestamped directory for the paths pruned
+// from dir, under the user cache directory so that the trash is neither
+// part of dir nor picked up by git
+func defaultTrash(dir string) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(abs) + "-" + time.Now().Format("20060102-150405")
+	return filepath.Join(cache, "buildtree", "trash", name), nil
+}
+
+// describeStep formats a step as a single line of the plan
+func describeStep(step reconcile.Step) string {
+	name := step.Path
+	if step.Type == diff.Dir {
+		name += "/"
+	}
+
+	switch step.Action {
+	case reconcile.Create:
+		return "+ " + name
+	case reconcile.Remove:
+		return "- " + name
+	}
+	return fmt.Sprintf("! %s (skipped: %s)", name, step.Reason)
+}
+
+// confirm asks a yes/no question, defaulting to no
+func confirm(stdin io.Reader, stdout io.Writer, question string) bool {
+	fmt.Fprintf(stdout, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func printSyncHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: buildtree sync [OPTIONS] SPEC [DIR]")
+	fmt.Fprintln(w, "Create missing paths so a directory (default: the spec's root name) matches the spec")
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  --prune		Also remove paths that are not in the spec")
+	fmt.Fprintln(w, "  -y, --yes		Apply removals without asking for confirmation")
+	fmt.Fprintln(w, "  --dry-run		Print the plan without changing anything")
+	fmt.Fprintln(w, "  --trash DIR		Move pruned paths into DIR (default: under the user cache directory)")
+	fmt.Fprintln(w, "  --no-trash		Delete pruned paths permanently")
+	fmt.Fprintln(w, "  --protect PATTERN	Never prune paths matching the glob (repeatable)")
+	fmt.Fprintln(w, "  --ignore PATTERN	Leave paths matching the glob alone (repeatable)")
+	fmt.Fprintln(w, "  -h, --help		Show this help message")
+	fmt.Fprintf(w, "Always protected: %s\n", strings.Join(reconcile.DefaultProtect, ", "))
+}
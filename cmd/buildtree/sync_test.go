@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSyncFixture(t *testing.T) (spec, dir string) {
+	t.Helper()
+	base := t.TempDir()
+	spec = filepath.Join(base, "spec.tree")
+	dir = filepath.Join(base, "project")
+
+	if err := os.WriteFile(spec, []byte("project/\n├── src/\n│   └── main.go\n└── go.mod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "old.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return spec, dir
+}
+
+func TestRunSync_CreateOnly(t *testing.T) {
+	spec, dir := writeSyncFixture(t)
+
+	stdout := &bytes.Buffer{}
+	exitCode := run([]string{"sync", spec, dir}, &bytes.Buffer{}, stdout, &bytes.Buffer{}, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d", exitCode)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src", "main.go")); err != nil {
+		t.Errorf("src/main.go should have been created: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); err != nil {
+		t.Error("old.txt should be kept without --prune")
+	}
+	if !strings.Contains(stdout.String(), "+ src/main.go") {
+		t.Errorf("Plan was not printed, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunSync_PruneConfirmation(t *testing.T) {
+	spec, dir := writeSyncFixture(t)
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+
+	// Declining leaves everything in place
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"sync", "--prune", spec, dir}, strings.NewReader("n\n"), &bytes.Buffer{}, stderr, &mockParser{}, &mockBuilder{})
+	if exitCode != 1 || !strings.Contains(stderr.String(), "Aborted") {
+		t.Errorf("Expected abort, got exit %d (stderr: %s)", exitCode, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); !os.IsNotExist(err) {
+		t.Error("Nothing should be created when aborted")
+	}
+
+	stdout := &bytes.Buffer{}
+	exitCode = run([]string{"sync", "--prune", spec, dir}, strings.NewReader("y\n"), stdout, &bytes.Buffer{}, &mockParser{}, &mockBuilder{})
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d", exitCode)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); !os.IsNotExist(err) {
+		t.Error("old.txt should have been pruned")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		t.Error(".git must never be pruned")
+	}
+
+	trashed, _ := filepath.Glob(filepath.Join(cache, "buildtree", "trash", "project-*", "old.txt"))
+	if len(trashed) != 1 {
+		t.Errorf("old.txt should have been moved to the trash, found %v", trashed)
+	}
+}
+
+func TestRunSync_DryRunAndDelete(t *testing.T) {
+	spec, dir := writeSyncFixture(t)
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+
+	stdout := &bytes.Buffer{}
+	exitCode := run([]string{"sync", "--prune", "--dry-run", spec, dir}, &bytes.Buffer{}, stdout, &bytes.Buffer{}, &mockParser{}, &mockBuilder{})
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d", exitCode)
+	}
+	if !strings.Contains(stdout.String(), "- old.txt") {
+		t.Errorf("Expected removal in plan, got:\n%s", stdout.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); err != nil {
+		t.Error("Dry run must not change anything")
+	}
+
+	exitCode = run([]string{"sync", "--prune", "--yes", "--no-trash", spec, dir}, &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}, &mockParser{}, &mockBuilder{})
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d", exitCode)
+	}
+	if _, err := os.Stat(filepath.Join(cache, "buildtree")); !os.IsNotExist(err) {
+		t.Error("No trash should be created with --no-trash")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); !os.IsNotExist(err) {
+		t.Error("old.txt should have been deleted")
+	}
+}
+
+func TestRunSync_PruneThenVerify(t *testing.T) {
+	spec, dir := writeSyncFixture(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	stderr := &bytes.Buffer{}
+	if code := run([]string{"sync", "--prune", "--yes", spec, dir}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &mockParser{}, &mockBuilder{}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	// The trash is outside the directory, which now matches the spec
+	stderr.Reset()
+	if code := run([]string{"verify", "--exhaustive", "--ignore", ".git", spec, dir}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &mockParser{}, &mockBuilder{}); code != 0 {
+		t.Errorf("Expected verify to pass after sync, got %d (stderr: %s)", code, stderr.String())
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/neomen/buildtree/internal/registry"
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// openStore returns the template store in the default location
+func openStore() (*registry.Store, error) {
+	dir, err := registry.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return &registry.Store{Dir: dir}, nil
+}
+
+// runTemplate manages the local template store
+func runTemplate(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p parserInterface, b builderInterface) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		w := stdout
+		if len(args) == 0 {
+			w = stderr
+		}
+		printTemplateHelp(w)
+		if len(args) == 0 {
+			return 1
+		}
+		return 0
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error locating templates: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "add":
+		return templateAdd(store, args[1:], stdout, stderr)
+	case "list":
+		return templateList(store, stdout, stderr)
+	case "show":
+		return templateShow(store, args[1:], stdout, stderr)
+	case "remove":
+		return templateRemove(store, args[1:], stderr)
+	}
+
+	printTemplateHelp(stderr)
+	fmt.Fprintf(stderr, "Error: Unknown template command '%s'\n", args[0])
+	return 1
+}
+
+func templateAdd(store *registry.Store, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("buildtree template add", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	description := flags.String("description", "", "Short description of the template")
+	force := flags.Bool("force", false, "Replace an existing template")
+
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return 1
+	}
+	if len(positional) != 2 {
+		fmt.Fprintln(stderr, "Usage: buildtree template add [--description TEXT] [--force] NAME SPEC")
+		return 1
+	}
+
+	tmpl, err := store.Add(positional[0], positional[1], registry.Metadata{Description: *description}, *force)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error adding template: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Added template '%s'\n", tmpl.Name)
+	return 0
+}
+
+func templateList(store *registry.Store, stdout, stderr io.Writer) int {
+	templates, err := store.List()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error listing templates: %v\n", err)
+		return 1
+	}
+
+	for _, tmpl := range templates {
+		if tmpl.Description != "" {
+			fmt.Fprintf(stdout, "%s\t%s\n", tmpl.Name, tmpl.Description)
+		} else {
+			fmt.Fprintln(stdout, tmpl.Name)
+		}
+	}
+	return 0
+}
+
+func templateShow(store *registry.Store, args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, "Usage: buildtree template show NAME")
+		return 1
+	}
+
+	tmpl, err := store.Get(args[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	root, err := tmpl.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading template: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Name: %s\n", tmpl.Name)
+	if tmpl.Description != "" {
+		fmt.Fprintf(stdout, "Description: %s\n", tmpl.Description)
+	}
+	if len(tmpl.Parameters) > 0 {
+		fmt.Fprintln(stdout, "Parameters:")
+		for _, param := range tmpl.Parameters {
+			line := "  " + param.Name
+			if param.Default != nil {
+				line += fmt.Sprintf(" (default: %v)", param.Default)
+			} else {
+				line += " (required)"
+			}
+			if param.Description != "" {
+				line += " - " + param.Description
+			}
+			fmt.Fprintln(stdout, line)
+		}
+	}
+	fmt.Fprintln(stdout, "Structure:")
+	if err := buildtree.Render(stdout, root, buildtree.RenderOptions{Comments: true}); err != nil {
+		fmt.Fprintf(stderr, "Error writing tree: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func templateRemove(store *registry.Store, args []string, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, "Usage: buildtree template remove NAME")
+		return 1
+	}
+	if err := store.Remove(args[0]); err != nil {
+		fmt.Fprintf(stderr, "Error removing template: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runNew builds a project from a stored template
+func runNew(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p parserInterface, b builderInterface) int {
+	flags := flag.NewFlagSet("buildtree new", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var assignments stringList
+	helpFlag := flags.Bool("help", false, "Show help")
+	valuesFile := flags.String("values", "", "YAML file with template values")
+	dryRun := flags.Bool("dry-run", false, "Print the expanded structure without creating it")
+	bf := newBuildFlags(flags)
+	flags.Var(&assignments, "set", "Set a template value as key=value (repeatable)")
+	flags.BoolVar(helpFlag, "h", false, "Alias for --help")
+
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return 1
+	}
+
+	if *helpFlag {
+		printNewHelp(stdout)
+		return 0
+	}
+	if err := bf.check(); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(positional) != 2 {
+		printNewHelp(stderr)
+		fmt.Fprintln(stderr, "Error: Template and project name are required")
+		return 1
+	}
+	templateName, name := positional[0], positional[1]
+
+	store, err := openStore()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error locating templates: %v\n", err)
+		return 1
+	}
+	tmpl, err := store.Get(templateName)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	root, err := tmpl.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading template: %v\n", err)
+		return 1
+	}
+
+	values, err := templateValues(*valuesFile, assignments)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error reading values: %v\n", err)
+		return 1
+	}
+	// The project name is always available as {{ .Name }}
+	if _, ok := values["Name"]; !ok {
+		values["Name"] = name
+	}
+	if values, err = tmpl.Values(values); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	root, err = buildtree.Resolve(ctx, root, buildtree.ParseOptions{Values: values})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error expanding variables: %v\n", err)
+		return 1
+	}
+	root.Name = name
+	return bf.build(stdout, stderr, b, root, *dryRun)
+}
+
+func printTemplateHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: buildtree template COMMAND [ARGS]")
+	fmt.Fprintln(w, "Manage templates stored in $XDG_CONFIG_HOME/buildtree/templates")
+	fmt.Fprintln(w, "Commands:")
+	fmt.Fprintln(w, "  add NAME SPEC		Store a spec file or template directory as NAME")
+	fmt.Fprintln(w, "			(--description TEXT, --force to replace)")
+	fmt.Fprintln(w, "  list			List stored templates")
+	fmt.Fprintln(w, "  show NAME		Show a template's parameters and structure")
+	fmt.Fprintln(w, "  remove NAME		Delete a template")
+	fmt.Fprintln(w, "A template directory holds spec.tree (or .json/.yaml), an optional template.yaml")
+	fmt.Fprintln(w, "declaring parameters, and an optional files/ directory with file contents.")
+}
+
+func printNewHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: buildtree new [OPTIONS] TEMPLATE NAME")
+	fmt.Fprintln(w, "Build a project called NAME from a stored template ({{ .Name }} is set to NAME)")
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  --set KEY=VALUE	Set a template value (repeatable)")
+	fmt.Fprintln(w, "  --values FILE		Read template values from a YAML file")
+	fmt.Fprintln(w, "  --dry-run		Print the expanded structure without creating it")
+	printBuildFlagsHelp(w)
+	fmt.Fprintln(w, "  -d, --max-depth N	Maximum nesting depth allowed (0=unlimited, default:20)")
+	fmt.Fprintln(w, "  -h, --help		Show this help message")
+}
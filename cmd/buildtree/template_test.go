@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+func TestRunTemplate_Lifecycle(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	spec := filepath.Join(t.TempDir(), "service.tree")
+	if err := os.WriteFile(spec, []byte("{{ .Name }}/\n└── {{ .Name }}.go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if code := run([]string{"template", "add", "svc", spec, "--description", "A service"}, &bytes.Buffer{}, stdout, stderr, &mockParser{}, &mockBuilder{}); code != 0 {
+		t.Fatalf("add: expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"template", "list"}, &bytes.Buffer{}, stdout, stderr, &mockParser{}, &mockBuilder{}); code != 0 {
+		t.Fatalf("list: expected exit code 0, got %d", code)
+	}
+	if stdout.String() != "svc\tA service\n" {
+		t.Errorf("Unexpected list output %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"template", "show", "svc"}, &bytes.Buffer{}, stdout, stderr, &mockParser{}, &mockBuilder{}); code != 0 {
+		t.Fatalf("show: expected exit code 0, got %d", code)
+	}
+	for _, want := range []string{"Name: svc", "Name (required)", "└── {{ .Name }}.go"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected show output to contain %q, got:\n%s", want, stdout.String())
+		}
+	}
+
+	if code := run([]string{"template", "remove", "svc"}, &bytes.Buffer{}, stdout, stderr, &mockParser{}, &mockBuilder{}); code != 0 {
+		t.Fatalf("remove: expected exit code 0, got %d", code)
+	}
+	stderr.Reset()
+	if code := run([]string{"template", "show", "svc"}, &bytes.Buffer{}, stdout, stderr, &mockParser{}, &mockBuilder{}); code != 1 {
+		t.Errorf("show after remove: expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "template not found") {
+		t.Errorf("Expected not found error, got %q", stderr.String())
+	}
+}
+
+func TestRunNew(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	spec := filepath.Join(t.TempDir(), "service.tree")
+	if err := os.WriteFile(spec, []byte("app/\n├── {{ .Name }}.go\n└── {{ .Env }}.yaml\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"template", "add", "svc", spec}, &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}, &mockParser{}, &mockBuilder{}); code != 0 {
+		t.Fatalf("add: expected exit code 0, got %d", code)
+	}
+
+	var built *tree.Node
+	b := &mockBuilder{
+		buildFunc: func(root *tree.Node, opts buildtree.BuildOptions) error {
+			built = root
+			return nil
+		},
+	}
+
+	// Missing required parameter
+	stderr := &bytes.Buffer{}
+	if code := run([]string{"new", "svc", "billing"}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &mockParser{}, b); code != 1 {
+		t.Errorf("Expected exit code 1 without Env, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "missing required parameters: Env") {
+		t.Errorf("Unexpected error %q", stderr.String())
+	}
+
+	if code := run([]string{"new", "svc", "billing", "--set", "Env=prod"}, &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}, &mockParser{}, b); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if built.Name != "billing" || built.Children[0].Name != "billing.go" || built.Children[1].Name != "prod.yaml" {
+		t.Errorf("Unexpected tree %+v", built)
+	}
+}
+
+func TestRunNew_OptionalBlocks(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	spec := filepath.Join(t.TempDir(), "service.tree")
+	if err := os.WriteFile(spec, []byte("app/\n├── @if docker\n│   └── Dockerfile\n└── main.go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"template", "add", "svc", spec}, &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}, &mockParser{}, &mockBuilder{}); code != 0 {
+		t.Fatalf("add: expected exit code 0, got %d", code)
+	}
+
+	for _, tt := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"new", "svc", "billing", "--dry-run"}, "billing/\n└── main.go\n"},
+		{[]string{"new", "svc", "billing", "--dry-run", "--set", "docker=true"}, "billing/\n├── Dockerfile\n└── main.go\n"},
+	} {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		if code := run(tt.args, &bytes.Buffer{}, stdout, stderr, &mockParser{}, &mockBuilder{}); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d (stderr: %s)", tt.args, code, stderr.String())
+		}
+		if stdout.String() != tt.expected {
+			t.Errorf("%v: expected %q, got %q", tt.args, tt.expected, stdout.String())
+		}
+	}
+}
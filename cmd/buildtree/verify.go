@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/neomen/buildtree/internal/diff"
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+// runVerify checks that a directory satisfies a structure spec
+func runVerify(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, p parserInterface, b builderInterface) int {
+	flags := flag.NewFlagSet("buildtree verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var ignore stringList
+	helpFlag := flags.Bool("help", false, "Show help")
+	exhaustive := flags.Bool("exhaustive", false, "Fail on paths that are not in the spec")
+	flags.Var(&ignore, "ignore", "Glob pattern of paths on disk to ignore (repeatable)")
+	flags.BoolVar(helpFlag, "h", false, "Alias for --help")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if *helpFlag {
+		printVerifyHelp(stdout)
+		return 0
+	}
+
+	if flags.NArg() < 1 {
+		printVerifyHelp(stderr)
+		fmt.Fprintln(stderr, "Error: No spec file provided")
+		return 1
+	}
+
+	specPath := flags.Arg(0)
+	spec, err := readSpec(specPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error parsing spec: %v\n", err)
+		return 1
+	}
+
+	dir := spec.Name
+	if flags.NArg() > 1 {
+		dir = flags.Arg(1)
+	}
+
+	changes, err := diff.Compare(spec, dir, diff.Options{Ignore: ignore})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error verifying: %v\n", err)
+		return 1
+	}
+
+	failures := 0
+	for _, change := range changes {
+		if change.Kind == diff.Extra && !*exhaustive {
+			continue
+		}
+		fmt.Fprintln(stderr, verifyMessage(specPath, change))
+		failures++
+	}
+
+	if failures > 0 {
+		fmt.Fprintf(stderr, "%d problem(s) found in %s\n", failures, dir)
+		return 1
+	}
+	return 0
+}
+
+// verifyMessage formats a change as "spec:line: message", citing the spec
+// node responsible for the path
+func verifyMessage(specPath string, change diff.Change) string {
+	var node *buildtree.Node
+	var message string
+
+	switch change.Kind {
+	case diff.Missing:
+		node = change.Node
+		message = fmt.Sprintf("missing %s '%s'", describeType(change.Want), change.Path)
+	case diff.TypeMismatch:
+		node = change.Node
+		message = fmt.Sprintf("'%s' should be a %s, found %s", change.Path, describeType(change.Want), describeType(change.Got))
+	case diff.Extra:
+		node = change.Parent
+		message = fmt.Sprintf("unexpected %s '%s'", describeType(change.Got), change.Path)
+	}
+
+	if node != nil && node.Line > 0 {
+		// Nodes spliced in by @include point at the file they came from
+		if node.File != "" {
+			specPath = node.File
+		}
+		return fmt.Sprintf("%s:%d: %s", specPath, node.Line, message)
+	}
+	return fmt.Sprintf("%s: %s", specPath, message)
+}
+
+func describeType(t diff.EntryType) string {
+	switch t {
+	case diff.Dir:
+		return "directory"
+	case diff.Symlink:
+		return "symbolic link"
+	}
+	return "file"
+}
+
+func printVerifyHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: buildtree verify [OPTIONS] SPEC [DIR]")
+	fmt.Fprintln(w, "Fail if a directory (default: the spec's root name) does not follow the spec")
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  --exhaustive		Also fail on paths that are not in the spec")
+	fmt.Fprintln(w, "  --ignore PATTERN	Ignore paths on disk matching the glob (repeatable)")
+	fmt.Fprintln(w, "  -h, --help		Show this help message")
+	fmt.Fprintln(w, "Spec names may use glob wildcards (*, ?, [...]) to match variable names.")
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const verifySpec = `service/
+├── cmd/
+│   └── */
+│       └── main.go
+├── go.mod
+└── README.md
+`
+
+func writeVerifyFixture(t *testing.T, files ...string) (spec, dir string) {
+	t.Helper()
+	base := t.TempDir()
+	spec = filepath.Join(base, "layout.tree")
+	dir = filepath.Join(base, "service")
+
+	if err := os.WriteFile(spec, []byte(verifySpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return spec, dir
+}
+
+func TestRunVerify_Pass(t *testing.T) {
+	spec, dir := writeVerifyFixture(t, "cmd/api/main.go", "cmd/worker/main.go", "go.mod", "README.md", "extra.txt")
+
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"verify", spec, dir}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d (stderr: %s)", exitCode, stderr.String())
+	}
+}
+
+func TestRunVerify_Failures(t *testing.T) {
+	spec, dir := writeVerifyFixture(t, "cmd/api/main.go", "cmd/worker/.keep", "go.mod/x", "extra.txt")
+
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"verify", spec, dir}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+
+	expected := []string{
+		spec + ":4: missing file 'cmd/worker/main.go'",
+		spec + ":5: 'go.mod' should be a file, found directory",
+		spec + ":6: missing file 'README.md'",
+		"3 problem(s) found",
+	}
+	for _, want := range expected {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, stderr.String())
+		}
+	}
+	if strings.Contains(stderr.String(), "extra.txt") {
+		t.Error("Extra files should be allowed without --exhaustive")
+	}
+}
+
+func TestRunVerify_Exhaustive(t *testing.T) {
+	spec, dir := writeVerifyFixture(t, "cmd/api/main.go", "cmd/api/util.go", "go.mod", "README.md", "extra.txt")
+
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"verify", "--exhaustive", "--ignore", "*.txt", spec, dir}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if want := spec + ":3: unexpected file 'cmd/api/util.go'"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("Expected output to contain %q, got:\n%s", want, stderr.String())
+	}
+	if strings.Contains(stderr.String(), "extra.txt") {
+		t.Error("Ignored files should not be reported")
+	}
+}
+
+func TestRunVerify_NoSpec(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"verify"}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "No spec file provided") {
+		t.Error("Error message was not printed")
+	}
+}
+
+func TestRunVerify_IncludedSpec(t *testing.T) {
+	base := t.TempDir()
+	spec := filepath.Join(base, "layout.tree")
+	shared := filepath.Join(base, "shared.tree")
+	if err := os.WriteFile(spec, []byte("service/\n├── @include shared.tree\n└── go.mod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(shared, []byte("shared/\n└── LICENSE\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(base, "service")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"verify", spec, dir}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), shared+":2: missing file 'LICENSE'") {
+		t.Errorf("Expected the failure to cite the included spec, got %q", stderr.String())
+	}
+}
+
+func TestRunVerify_InvalidNames(t *testing.T) {
+	base := t.TempDir()
+	spec := filepath.Join(base, "bad.tree")
+	if err := os.WriteFile(spec, []byte("bad/\n├── a<b.txt\n├── CON\n└── *.go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"verify", spec, base}, &bytes.Buffer{}, &bytes.Buffer{}, stderr, &mockParser{}, &mockBuilder{})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	for _, expected := range []string{
+		spec + ":2: invalid name: forbidden character '<' at position 2",
+		spec + ":3: invalid name: reserved Windows name 'CON'",
+	} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("Expected %q in stderr, got %q", expected, stderr.String())
+		}
+	}
+	if strings.Contains(stderr.String(), "*.go") || strings.Contains(stderr.String(), "missing") {
+		t.Errorf("Expected only the invalid names to be reported, got %q", stderr.String())
+	}
+}
@@ -1,3 +1,8 @@
 module github.com/neomen/buildtree
 
 go 1.24.5
+
+require (
+	golang.org/x/text v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -1,71 +1,263 @@
 package builder
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
-	"github.com/neomen/buildtree/internal/parser"
 	"github.com/neomen/buildtree/internal/validator"
+	"github.com/neomen/buildtree/pkg/tree"
 )
 
+// ContentProvider supplies the initial content of files the spec leaves empty
+type ContentProvider interface {
+	// Content returns the content and permissions for the file at path,
+	// or "" to leave it empty
+	Content(path string) (string, fs.FileMode, error)
+}
+
+var (
+	// ErrInvalidRoot is returned when the root of the tree is not a valid path
+	ErrInvalidRoot = errors.New("invalid root node name")
+	// ErrInvalidPlaceholder is returned when Options.Placeholder is not a valid name
+	ErrInvalidPlaceholder = errors.New("invalid placeholder name")
+)
+
+// DefaultPlaceholder is the file that keeps empty directories in git
+const DefaultPlaceholder = ".gitkeep"
+
+// Options control how a tree is built
+type Options struct {
+	MaxDepth int             // Maximum nesting depth (0 = no limit)
+	Content  ContentProvider // Fills files without content, if set
+	// Placeholder is a file name such as ".gitkeep", ".keep" or "README.md"
+	// created in every directory left empty by the build ("" = none)
+	Placeholder string
+	Observer    Observer // Receives an event for every path, if set
+	// Jobs is the number of paths created in parallel. Above 1, directories
+	// are created level by level and the Content provider must be safe for
+	// concurrent use; events are delivered in tree order once each path is
+	// done. 0 or 1 builds sequentially.
+	Jobs int
+	// NoRoot builds the children of the root in the current directory
+	// instead of a directory named after the root. It is implied for a
+	// root named tree.CurrentDir.
+	NoRoot bool
+	// Dir is the directory the tree is built in, created if missing
+	// ("" = the current directory). Paths in the report start with it.
+	Dir string
+}
+
 // BuildTree creates the file structure from the parsed tree
-func BuildTree(root *parser.Node, maxDepth int) error {
-	if maxDepth < 0 {
-		maxDepth = 0
-		log.Println("Warning: Negative max-depth value corrected to 0 (no limit)")
+func BuildTree(root *tree.Node, maxDepth int) error {
+	_, err := Build(root, Options{MaxDepth: maxDepth})
+	return err
+}
+
+// Build creates the file structure from the parsed tree with the given options
+func Build(root *tree.Node, opts Options) (*Report, error) {
+	return BuildContext(context.Background(), root, opts)
+}
+
+// BuildContext is like Build but stops before the next path once ctx is
+// done, returning ctx.Err(); paths already created are kept
+func BuildContext(ctx context.Context, root *tree.Node, opts Options) (*Report, error) {
+	// A negative max depth means no limit, like 0
+	if opts.MaxDepth < 0 {
+		opts.MaxDepth = 0
+	}
+	if root.Name == tree.CurrentDir {
+		opts.NoRoot = true
 	}
 
 	// Validate root node name
-	if !validator.IsValidPath(root.Name) {
-		return fmt.Errorf("invalid root node name: '%s'", root.Name)
+	if !opts.NoRoot {
+		if err := validator.Validate(root.Name); err != nil {
+			return nil, fmt.Errorf("%w: '%s' (%v)", ErrInvalidRoot, root.Name, err)
+		}
+	}
+	if err := validatePlaceholder(opts.Placeholder); err != nil {
+		return nil, err
+	}
+	if err := makeOutputDir(opts.Dir); err != nil {
+		return nil, err
 	}
 
-	// Create root directory
-	if err := createNode(root, "", maxDepth, 0); err != nil {
+	report := &Report{Root: root.Name}
+	if opts.NoRoot {
+		report.Root = "."
+	}
+	if opts.Jobs > 1 {
+		return report, buildParallel(ctx, root, opts, report)
+	}
+	return report, buildSequential(ctx, root, opts, report)
+}
+
+// buildSequential creates the tree one path at a time, in tree order.
+// Directories are filled before their placeholder is considered.
+func buildSequential(ctx context.Context, root *tree.Node, opts Options, report *Report) error {
+	// paths[d] is the path of the open directory at depth d
+	var paths []string
+	pre := func(node *tree.Node, depth int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		fullPath := filepath.Join(opts.Dir, node.Name)
+		if depth > 0 {
+			fullPath = filepath.Join(paths[depth-1], node.Name)
+		} else if opts.NoRoot {
+			// Children are joined to opts.Dir and built right in it
+			paths = append(paths[:0], opts.Dir)
+			return nil
+		}
+		paths = append(paths[:depth], fullPath)
+
+		if kind, reason := skipNode(node, opts, depth); kind != "" {
+			report.record(opts.Observer, kind, fullPath, node, node.IsDir, reason)
+			return tree.SkipSubtree
+		}
+
+		if !node.IsDir {
+			outcome, err := makeFile(node, fullPath, opts)
+			report.record(opts.Observer, outcome, fullPath, node, false, err)
+			if err != nil {
+				return err
+			}
+			return tree.SkipSubtree
+		}
+
+		outcome, err := makeDir(fullPath)
+		report.record(opts.Observer, outcome, fullPath, node, true, err)
 		return err
 	}
 
-	if maxDepth > 0 {
-		log.Printf("Created structure with max depth %d", maxDepth)
+	var post tree.WalkFunc
+	if opts.Placeholder != "" {
+		post = func(node *tree.Node, depth int) error {
+			if depth == 0 && opts.NoRoot {
+				return nil
+			}
+			return keepEmpty(paths[depth], opts, report)
+		}
 	}
-	return nil
+	return root.Walk(pre, post)
 }
 
-func createNode(node *parser.Node, parentPath string, maxDepth, currentDepth int) error {
-	fullPath := filepath.Join(parentPath, node.Name)
-
+// skipNode returns the event kind and reason for a node that is not built
+// because of its depth or name, or "" if it should be built
+func skipNode(node *tree.Node, opts Options, depth int) (EventKind, error) {
 	// Check max depth
-	if maxDepth > 0 && currentDepth > maxDepth {
-		log.Printf("Skipping '%s' - exceeds max depth (%d)", fullPath, maxDepth)
-		return nil
+	if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+		return SkippedDepth, fmt.Errorf("exceeds max depth (%d)", opts.MaxDepth)
+	}
+
+	// Directives are resolved before building; any left over are not paths
+	if node.Directive != "" {
+		return SkippedInvalid, fmt.Errorf("unresolved @%s directive", node.Directive)
 	}
 
 	// Validate path
-	if !validator.IsValidPath(node.Name) {
-		log.Printf("Invalid name '%s' - skipping", node.Name)
+	if err := validator.Validate(node.Name); err != nil {
+		return SkippedInvalid, err
+	}
+	return "", nil
+}
+
+// validatePlaceholder checks the Options.Placeholder name, if any
+func validatePlaceholder(name string) error {
+	if name == "" {
 		return nil
 	}
+	if err := validator.Validate(name); err != nil {
+		return fmt.Errorf("%w: '%s' (%v)", ErrInvalidPlaceholder, name, err)
+	}
+	return nil
+}
 
-	if node.IsDir {
-		// Create directory
-		if err := os.MkdirAll(fullPath, 0755); err != nil && !os.IsExist(err) {
-			return err
-		}
+// makeOutputDir creates the Options.Dir a tree is built in, if any
+func makeOutputDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	return os.MkdirAll(dir, 0755)
+}
 
-		// Process children
-		for _, child := range node.Children {
-			if err := createNode(child, fullPath, maxDepth, currentDepth+1); err != nil {
-				return err
-			}
+// makeDir creates a directory whose parent exists
+func makeDir(path string) (EventKind, error) {
+	outcome := existence(path)
+	if err := os.MkdirAll(path, 0755); err != nil && !os.IsExist(err) {
+		return Failed, err
+	}
+	return outcome, nil
+}
+
+// makeFile writes a file, falling back to a skeleton when the spec gives
+// no content
+func makeFile(node *tree.Node, path string, opts Options) (EventKind, error) {
+	outcome := existence(path)
+	content, mode := node.Content, fs.FileMode(0644)
+	// Skeletons only fill files the build creates
+	if content == "" && opts.Content != nil && outcome == Created {
+		skeleton, skeletonMode, err := opts.Content.Content(path)
+		if err != nil {
+			return Failed, err
 		}
-	} else {
-		// Create file
-		if err := os.WriteFile(fullPath, []byte{}, 0644); err != nil {
-			return err
+		if skeleton != "" {
+			content, mode = skeleton, skeletonMode
 		}
 	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		return Failed, err
+	}
+	return outcome, nil
+}
 
+// existence returns Existed if something is already at path, else Created
+func existence(path string) EventKind {
+	if _, err := os.Lstat(path); err == nil {
+		return Existed
+	}
+	return Created
+}
+
+// keepEmpty adds a placeholder file to dir if it is still empty
+func keepEmpty(dir string, opts Options, report *Report) error {
+	path, added, err := addPlaceholder(dir, opts.Placeholder)
+	if err != nil {
+		report.record(opts.Observer, Failed, path, nil, false, err)
+		return err
+	}
+	if added {
+		report.record(opts.Observer, Placeholder, path, nil, false, nil)
+	}
 	return nil
 }
+
+// addPlaceholder writes the placeholder into dir if dir is empty and
+// reports whether it did
+func addPlaceholder(dir, placeholder string) (string, bool, error) {
+	path := filepath.Join(dir, placeholder)
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) > 0 {
+		return path, false, err
+	}
+
+	if err := os.WriteFile(path, []byte(placeholderContent(dir, placeholder)), 0644); err != nil {
+		return path, false, err
+	}
+	return path, true, nil
+}
+
+// placeholderContent returns a short stub for README placeholders; other
+// placeholders such as .gitkeep are empty
+func placeholderContent(dir, placeholder string) string {
+	if !strings.HasPrefix(strings.ToUpper(placeholder), "README") {
+		return ""
+	}
+	return fmt.Sprintf("# %s\n\nThis directory is intentionally empty.\n", filepath.Base(dir))
+}
@@ -1,11 +1,16 @@
 package builder
 
 import (
+	"context"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/pkg/tree"
 )
 
 func TestBuildTree_SimpleStructure(t *testing.T) {
@@ -22,16 +27,16 @@ func TestBuildTree_SimpleStructure(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	root := &parser.Node{
+	root := &tree.Node{
 		Name:  "project",
 		IsDir: true,
 		Level: 0,
-		Children: []*parser.Node{
+		Children: []*tree.Node{
 			{
 				Name:  "src",
 				IsDir: true,
 				Level: 1,
-				Children: []*parser.Node{
+				Children: []*tree.Node{
 					{
 						Name:  "main.go",
 						IsDir: false,
@@ -72,21 +77,21 @@ func TestBuildTree_ComplexStructure(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	root := &parser.Node{
+	root := &tree.Node{
 		Name:  "app",
 		IsDir: true,
 		Level: 0,
-		Children: []*parser.Node{
+		Children: []*tree.Node{
 			{
 				Name:  "src",
 				IsDir: true,
 				Level: 1,
-				Children: []*parser.Node{
+				Children: []*tree.Node{
 					{
 						Name:  "components",
 						IsDir: true,
 						Level: 2,
-						Children: []*parser.Node{
+						Children: []*tree.Node{
 							{
 								Name:  "Button.js",
 								IsDir: false,
@@ -103,7 +108,7 @@ func TestBuildTree_ComplexStructure(t *testing.T) {
 						Name:  "utils",
 						IsDir: true,
 						Level: 2,
-						Children: []*parser.Node{
+						Children: []*tree.Node{
 							{
 								Name:  "helpers.js",
 								IsDir: false,
@@ -122,7 +127,7 @@ func TestBuildTree_ComplexStructure(t *testing.T) {
 				Name:  "public",
 				IsDir: true,
 				Level: 1,
-				Children: []*parser.Node{
+				Children: []*tree.Node{
 					{
 						Name:  "index.html",
 						IsDir: false,
@@ -170,26 +175,26 @@ func TestBuildTree_MaxDepth(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	root := &parser.Node{
+	root := &tree.Node{
 		Name:  "project",
 		IsDir: true,
 		Level: 0,
-		Children: []*parser.Node{
+		Children: []*tree.Node{
 			{
 				Name:  "level1",
 				IsDir: true,
 				Level: 1,
-				Children: []*parser.Node{
+				Children: []*tree.Node{
 					{
 						Name:  "level2",
 						IsDir: true,
 						Level: 2,
-						Children: []*parser.Node{
+						Children: []*tree.Node{
 							{
 								Name:  "level3",
 								IsDir: true,
 								Level: 3,
-								Children: []*parser.Node{
+								Children: []*tree.Node{
 									{
 										Name:  "file.txt",
 										IsDir: false,
@@ -239,16 +244,16 @@ func TestBuildTree_ExistingDirectories(t *testing.T) {
 		t.Fatalf("Failed to create directory: %v", err)
 	}
 
-	root := &parser.Node{
+	root := &tree.Node{
 		Name:  "project",
 		IsDir: true,
 		Level: 0,
-		Children: []*parser.Node{
+		Children: []*tree.Node{
 			{
 				Name:  "src",
 				IsDir: true,
 				Level: 1,
-				Children: []*parser.Node{
+				Children: []*tree.Node{
 					{
 						Name:  "main.go",
 						IsDir: false,
@@ -283,11 +288,11 @@ func TestBuildTree_InvalidNames(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	root := &parser.Node{
+	root := &tree.Node{
 		Name:  "project",
 		IsDir: true,
 		Level: 0,
-		Children: []*parser.Node{
+		Children: []*tree.Node{
 			{
 				Name:  "valid_dir",
 				IsDir: true,
@@ -337,11 +342,11 @@ func TestBuildTree_DotDotPath(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	root := &parser.Node{
+	root := &tree.Node{
 		Name:  "project",
 		IsDir: true,
 		Level: 0,
-		Children: []*parser.Node{
+		Children: []*tree.Node{
 			{
 				Name:  "..", // Attempt to navigate up
 				IsDir: true,
@@ -398,11 +403,11 @@ func TestBuildTree_WindowsReservedNames(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	root := &parser.Node{
+	root := &tree.Node{
 		Name:  "project",
 		IsDir: true,
 		Level: 0,
-		Children: []*parser.Node{
+		Children: []*tree.Node{
 			{
 				// Windows reserved name
 				Name:  "CON",
@@ -483,7 +488,7 @@ func TestBuildTree_EmptyNode(t *testing.T) {
 	}
 
 	// Test with an empty node (should return error)
-	root := &parser.Node{
+	root := &tree.Node{
 		Name:  "",
 		IsDir: true,
 		Level: 0,
@@ -544,3 +549,325 @@ func assertNotExists(t *testing.T, path string) {
 func isWindowsInvalidNameError(err error) bool {
 	return strings.Contains(err.Error(), "The filename, directory name, or volume label syntax is incorrect")
 }
+
+func TestBuildTree_FileContent(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(originalDir)
+
+	err = os.Chdir(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := &tree.Node{
+		Name:  "project",
+		IsDir: true,
+		Level: 0,
+		Children: []*tree.Node{
+			{
+				Name:    "main.go",
+				IsDir:   false,
+				Level:   1,
+				Content: "package main\n",
+			},
+		},
+	}
+
+	err = BuildTree(root, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile("project/main.go")
+	if err != nil {
+		t.Fatalf("Error reading file: %v", err)
+	}
+	if string(content) != "package main\n" {
+		t.Errorf("Expected content %q, got %q", "package main\n", content)
+	}
+}
+
+// stubContent returns fixed content for ".txt" files
+type stubContent struct {
+	paths []string
+}
+
+func (s *stubContent) Content(path string) (string, fs.FileMode, error) {
+	s.paths = append(s.paths, path)
+	if strings.HasSuffix(path, ".txt") {
+		return "skeleton\n", 0755, nil
+	}
+	return "", 0, nil
+}
+
+func TestBuild_ContentProvider(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(originalDir)
+
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatal(err)
+	}
+
+	root := &tree.Node{
+		Name:  "project",
+		IsDir: true,
+		Children: []*tree.Node{
+			{Name: "empty.txt", Level: 1},
+			{Name: "given.txt", Level: 1, Content: "from spec\n"},
+			{Name: "other.md", Level: 1},
+		},
+	}
+
+	provider := &stubContent{}
+	if _, err := Build(root, Options{Content: provider}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{"empty.txt": "skeleton\n", "given.txt": "from spec\n", "other.md": ""}
+	for name, want := range expected {
+		content, err := os.ReadFile(filepath.Join("project", name))
+		if err != nil {
+			t.Fatalf("Error reading %s: %v", name, err)
+		}
+		if string(content) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, content)
+		}
+	}
+
+	if info, err := os.Stat("project/empty.txt"); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("Expected the skeleton's mode on empty.txt, got %v (%v)", info.Mode(), err)
+	}
+	if len(provider.paths) != 2 {
+		t.Errorf("Files with content should not consult the provider, got %v", provider.paths)
+	}
+}
+
+func TestBuild_ContentProviderExistingFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll("project", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("project/existing.txt", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	root := &tree.Node{
+		Name:     "project",
+		IsDir:    true,
+		Children: []*tree.Node{{Name: "existing.txt", Level: 1}, {Name: "new.txt", Level: 1}},
+	}
+	provider := &stubContent{}
+	if _, err := Build(root, Options{Content: provider}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if content, err := os.ReadFile("project/existing.txt"); err != nil || len(content) != 0 {
+		t.Errorf("Expected existing.txt to stay empty, got %q (%v)", content, err)
+	}
+	if info, err := os.Stat("project/existing.txt"); err != nil || info.Mode().Perm()&0100 != 0 {
+		t.Errorf("Expected existing.txt to keep its mode, got %v (%v)", info.Mode(), err)
+	}
+	if content, err := os.ReadFile("project/new.txt"); err != nil || string(content) != "skeleton\n" {
+		t.Errorf("Expected the skeleton in new.txt, got %q (%v)", content, err)
+	}
+	if !reflect.DeepEqual(provider.paths, []string{filepath.Join("project", "new.txt")}) {
+		t.Errorf("Only the created file should consult the provider, got %v", provider.paths)
+	}
+}
+
+func TestBuild_KeepEmpty(t *testing.T) {
+	tests := []struct {
+		placeholder string
+		content     string
+	}{
+		{".gitkeep", ""},
+		{".keep", ""},
+		{"README.md", "# logs\n\nThis directory is intentionally empty.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.placeholder, func(t *testing.T) {
+			tempDir := t.TempDir()
+			originalDir, err := os.Getwd()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Chdir(originalDir)
+
+			if err := os.Chdir(tempDir); err != nil {
+				t.Fatal(err)
+			}
+
+			root := &tree.Node{
+				Name:  "project",
+				IsDir: true,
+				Children: []*tree.Node{
+					{Name: "logs", IsDir: true, Level: 1},
+					{
+						Name:     "src",
+						IsDir:    true,
+						Level:    1,
+						Children: []*tree.Node{{Name: "main.go", Level: 2}},
+					},
+					{
+						Name:     "tmp",
+						IsDir:    true,
+						Level:    1,
+						Children: []*tree.Node{{Name: "bad:name", Level: 2}},
+					},
+				},
+			}
+
+			report, err := Build(root, Options{Placeholder: tt.placeholder})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			expected := []string{
+				filepath.Join("project", "logs", tt.placeholder),
+				filepath.Join("project", "tmp", tt.placeholder),
+			}
+			if !reflect.DeepEqual(report.Placeholders, expected) {
+				t.Errorf("Expected placeholders %v, got %v", expected, report.Placeholders)
+			}
+			if files := []string{filepath.Join("project", "src", "main.go")}; !reflect.DeepEqual(report.Files, files) {
+				t.Errorf("Placeholders should not be listed as files, got %v", report.Files)
+			}
+
+			content, err := os.ReadFile(expected[0])
+			if err != nil {
+				t.Fatalf("Error reading placeholder: %v", err)
+			}
+			if string(content) != tt.content {
+				t.Errorf("Expected content %q, got %q", tt.content, content)
+			}
+			if _, err := os.Stat(filepath.Join("project", "src", tt.placeholder)); !os.IsNotExist(err) {
+				t.Error("Directories with children should not get a placeholder")
+			}
+		})
+	}
+}
+
+func TestBuild_InvalidPlaceholder(t *testing.T) {
+	root := &tree.Node{Name: "project", IsDir: true}
+	if _, err := Build(root, Options{Placeholder: "../.gitkeep"}); err == nil {
+		t.Error("Expected error for invalid placeholder name")
+	}
+}
+
+func TestBuild_DeepTree(t *testing.T) {
+	// Far deeper than any file system allows: the build must fail with an
+	// error for the path that is too long rather than exhaust the stack
+	const depth = 50000
+	root := &tree.Node{Name: "d", IsDir: true}
+	node := root
+	for i := 1; i <= depth; i++ {
+		child := &tree.Node{Name: "d", IsDir: true, Level: i}
+		node.Children = []*tree.Node{child}
+		node = child
+	}
+
+	for _, jobs := range []int{1, 4} {
+		t.Chdir(t.TempDir())
+
+		report, err := Build(root, Options{Jobs: jobs, Placeholder: DefaultPlaceholder})
+		if err == nil {
+			t.Fatalf("jobs=%d: expected an error for a %d-level tree", jobs, depth)
+		}
+		counts := report.Counts()
+		if counts[Failed] != 1 || counts[Created] == 0 || counts[Placeholder] != 0 {
+			t.Errorf("jobs=%d: unexpected counts %v", jobs, counts)
+		}
+		last := report.Events[len(report.Events)-1]
+		if last.Kind != Failed || last.Err != err {
+			t.Errorf("jobs=%d: expected the last event to be the failure, got %+v", jobs, last)
+		}
+	}
+}
+
+func TestBuild_NoRoot(t *testing.T) {
+	spec := "project/\n├── src/\n│   └── main.go\n├── logs/\n└── bad:name"
+	root, err := parser.ParseInput(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"src", "src/main.go", "logs", "logs/.gitkeep", "bad:name"}
+
+	builds := map[string]func(opts Options) (*Report, error){
+		"sequential": func(opts Options) (*Report, error) { return Build(root, opts) },
+		"parallel": func(opts Options) (*Report, error) {
+			opts.Jobs = 4
+			return Build(root, opts)
+		},
+		"stream": func(opts Options) (*Report, error) {
+			return BuildStream(context.Background(), parser.NewScanner(strings.NewReader(spec)), opts)
+		},
+	}
+	for name, build := range builds {
+		t.Chdir(t.TempDir())
+
+		var paths []string
+		report, err := build(Options{NoRoot: true, Placeholder: DefaultPlaceholder, Observer: func(event Event) {
+			paths = append(paths, event.Path)
+		}})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if report.Root != "." {
+			t.Errorf("%s: expected root '.', got %q", name, report.Root)
+		}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Errorf("%s: expected events for %v, got %v", name, expected, paths)
+		}
+		assertFileExists(t, filepath.Join("src", "main.go"))
+		assertNotExists(t, "project")
+		assertNotExists(t, DefaultPlaceholder)
+	}
+}
+
+func TestBuild_CurrentDirRoot(t *testing.T) {
+	spec := "├── src/\n│   └── main.go\n└── logs/"
+	root, err := parser.ParseInput(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"src", "src/main.go", "logs", "logs/.gitkeep"}
+
+	builds := map[string]func(opts Options) (*Report, error){
+		"sequential": func(opts Options) (*Report, error) { return Build(root, opts) },
+		"parallel": func(opts Options) (*Report, error) {
+			opts.Jobs = 4
+			return Build(root, opts)
+		},
+		"stream": func(opts Options) (*Report, error) {
+			return BuildStream(context.Background(), parser.NewScanner(strings.NewReader(spec)), opts)
+		},
+	}
+	for name, build := range builds {
+		t.Chdir(t.TempDir())
+
+		var paths []string
+		report, err := build(Options{Placeholder: DefaultPlaceholder, Observer: func(event Event) {
+			paths = append(paths, event.Path)
+		}})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if report.Root != tree.CurrentDir {
+			t.Errorf("%s: expected root %q, got %q", name, tree.CurrentDir, report.Root)
+		}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Errorf("%s: expected events for %v, got %v", name, expected, paths)
+		}
+		assertFileExists(t, filepath.Join("src", "main.go"))
+		assertNotExists(t, DefaultPlaceholder)
+	}
+}
@@ -0,0 +1,105 @@
+package builder
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/neomen/buildtree/internal/validator"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// EventKind is the outcome of a build for one path
+type EventKind string
+
+const (
+	Created        EventKind = "created"
+	Existed        EventKind = "existed" // Already on disk; files are rewritten
+	SkippedInvalid EventKind = "skipped-invalid"
+	SkippedDepth   EventKind = "skipped-depth" // The subtree below is skipped too
+	Failed         EventKind = "error"
+	Placeholder    EventKind = "placeholder" // Added by Options.Placeholder
+)
+
+// EventKinds lists all kinds in the order they are reported
+var EventKinds = []EventKind{Created, Existed, SkippedInvalid, SkippedDepth, Failed, Placeholder}
+
+// Event reports what happened to a path during a build
+type Event struct {
+	Kind   EventKind `json:"outcome"`
+	Path   string    `json:"path"` // Slash-separated, as built
+	IsDir  bool      `json:"-"`
+	Type   string    `json:"type"`             // "dir" or "file"
+	Reason string    `json:"reason,omitempty"` // Why a path was skipped or failed
+	// Violations are the rules an invalid name breaks, for SkippedInvalid
+	Violations []validator.Violation `json:"violations,omitempty"`
+	Err        error                 `json:"-"` // Set for Failed events
+	Node       *tree.Node            `json:"-"`
+}
+
+// Observer receives build events as they happen
+type Observer func(Event)
+
+// Report lists the outcome of every path in a build. Placeholders are
+// listed apart from the files of the spec so that they can be removed later.
+// Reports of streamed builds only hold the counts.
+type Report struct {
+	Root         string   // Name of the root directory
+	Dirs         []string // Directories created or already present
+	Files        []string // Files written
+	Placeholders []string
+	Events       []Event
+
+	counts     map[EventKind]int
+	countsOnly bool
+}
+
+// Counts returns the number of events of each kind
+func (r *Report) Counts() map[EventKind]int {
+	counts := make(map[EventKind]int, len(EventKinds))
+	for _, kind := range EventKinds {
+		counts[kind] = r.counts[kind]
+	}
+	return counts
+}
+
+// record adds an event to the report and passes it to the observer
+func (r *Report) record(observer Observer, kind EventKind, path string, node *tree.Node, isDir bool, err error) {
+	event := Event{Kind: kind, Path: filepath.ToSlash(path), IsDir: isDir, Type: "file", Node: node}
+	if isDir {
+		event.Type = "dir"
+	}
+	if err != nil {
+		event.Reason = err.Error()
+		var nameErr *validator.NameError
+		if errors.As(err, &nameErr) {
+			event.Violations = nameErr.Violations
+		}
+		if kind == Failed {
+			event.Err = err
+		}
+	}
+
+	if r.counts == nil {
+		r.counts = map[EventKind]int{}
+	}
+	r.counts[kind]++
+
+	if observer != nil {
+		defer observer(event)
+	}
+	if r.countsOnly {
+		return
+	}
+
+	switch kind {
+	case Created, Existed:
+		if isDir {
+			r.Dirs = append(r.Dirs, path)
+		} else {
+			r.Files = append(r.Files, path)
+		}
+	case Placeholder:
+		r.Placeholders = append(r.Placeholders, path)
+	}
+	r.Events = append(r.Events, event)
+}
@@ -0,0 +1,109 @@
+package builder
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+func TestBuild_Events(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll("project/src", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	root := &tree.Node{
+		Name:  "project",
+		IsDir: true,
+		Children: []*tree.Node{
+			{
+				Name:  "src",
+				IsDir: true,
+				Level: 1,
+				Children: []*tree.Node{
+					{
+						Name:     "deep",
+						IsDir:    true,
+						Level:    2,
+						Children: []*tree.Node{{Name: "too-deep.txt", Level: 3}},
+					},
+				},
+			},
+			{Name: "bad:name", Level: 1},
+			{Name: "lib.tree", Directive: parser.DirectiveInclude, Level: 1},
+			{Name: "empty", IsDir: true, Level: 1},
+		},
+	}
+
+	var observed []Event
+	report, err := Build(root, Options{
+		MaxDepth:    2,
+		Placeholder: DefaultPlaceholder,
+		Observer:    func(event Event) { observed = append(observed, event) },
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	type outcome struct {
+		kind   EventKind
+		path   string
+		reason string
+	}
+	var got []outcome
+	for _, event := range observed {
+		got = append(got, outcome{event.Kind, event.Path, event.Reason})
+	}
+	expected := []outcome{
+		{Existed, "project", ""},
+		{Existed, "project/src", ""},
+		{Created, "project/src/deep", ""},
+		{SkippedDepth, "project/src/deep/too-deep.txt", "exceeds max depth (2)"},
+		{Placeholder, "project/src/deep/.gitkeep", ""},
+		{SkippedInvalid, "project/bad:name", "invalid name: forbidden character ':' at position 4"},
+		{SkippedInvalid, "project/lib.tree", "unresolved @include directive"},
+		{Created, "project/empty", ""},
+		{Placeholder, "project/empty/.gitkeep", ""},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected events:\n got %v\nwant %v", got, expected)
+	}
+	if !reflect.DeepEqual(report.Events, observed) {
+		t.Error("The report should hold the observed events")
+	}
+
+	counts := report.Counts()
+	want := map[EventKind]int{Created: 2, Existed: 2, SkippedInvalid: 2, SkippedDepth: 1, Failed: 0, Placeholder: 2}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("Expected counts %v, got %v", want, counts)
+	}
+}
+
+func TestBuild_ErrorEvent(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("project", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var failed []Event
+	report, err := Build(&tree.Node{Name: "project", IsDir: true}, Options{
+		Observer: func(event Event) {
+			if event.Kind == Failed {
+				failed = append(failed, event)
+			}
+		},
+	})
+	if err == nil {
+		t.Fatal("Expected an error when the root is a file")
+	}
+	if len(failed) != 1 || failed[0].Path != "project" || !errors.Is(err, failed[0].Err) {
+		t.Errorf("Expected one error event for the root, got %+v", failed)
+	}
+	if report.Counts()[Failed] != 1 {
+		t.Errorf("Expected the error in the report, got %v", report.Counts())
+	}
+}
@@ -0,0 +1,206 @@
+package builder
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// job is a node of the tree being built in parallel, with its outcome
+type job struct {
+	node  *tree.Node
+	path  string
+	depth int
+	index int // Position of the node in tree order
+	kind  EventKind
+	err   error
+	// quiet marks the root under Options.NoRoot, which is not built and
+	// has no event
+	quiet bool
+
+	placeholder    string // Path of the placeholder added, if any
+	placeholderErr error
+}
+
+// buildParallel creates the tree level by level with opts.Jobs workers.
+// Each level is finished before the next one starts, so parents always
+// exist. Events are recorded in the same order as a sequential build, and
+// the error returned is the first one in that order.
+//
+// After a failure, no job that comes later in tree order is started, while
+// the subtrees before it are still completed. Paths later in tree order
+// that were created before the failure are removed again, so that the
+// paths left behind are those of a sequential build.
+func buildParallel(ctx context.Context, root *tree.Node, opts Options, report *Report) error {
+	index := map[*tree.Node]int{}
+	root.Walk(func(node *tree.Node, _ int) error {
+		index[node] = len(index)
+		return nil
+	}, nil)
+
+	top := &job{node: root, path: filepath.Join(opts.Dir, root.Name)}
+	if opts.NoRoot {
+		top = &job{node: root, path: opts.Dir, kind: Existed, quiet: true}
+	}
+	jobs := map[*tree.Node]*job{root: top}
+	var dirs []*job
+
+	// failed is the tree order index of the first failed job so far
+	var failed atomic.Int64
+	failed.Store(math.MaxInt64)
+
+	level := []*job{top}
+	for len(level) > 0 && ctx.Err() == nil {
+		forEach(opts.Jobs, level, func(j *job) {
+			// Jobs not run once ctx is done or after a failure have no event
+			if ctx.Err() != nil || j.quiet || int64(j.index) > failed.Load() {
+				return
+			}
+			j.run(opts)
+			for j.kind == Failed {
+				current := failed.Load()
+				if int64(j.index) >= current || failed.CompareAndSwap(current, int64(j.index)) {
+					break
+				}
+			}
+		})
+
+		var next []*job
+		for _, j := range level {
+			if !j.node.IsDir || (j.kind != Created && j.kind != Existed) {
+				continue
+			}
+			if !j.quiet {
+				dirs = append(dirs, j)
+			}
+			for _, child := range j.node.Children {
+				if int64(index[child]) > failed.Load() {
+					continue
+				}
+				c := &job{node: child, path: filepath.Join(j.path, child.Name), depth: j.depth + 1, index: index[child]}
+				jobs[child] = c
+				next = append(next, c)
+			}
+		}
+		level = next
+	}
+	if first := failed.Load(); first != math.MaxInt64 {
+		undoAfter(jobs, int(first))
+	}
+
+	if opts.Placeholder != "" && firstError(root, jobs) == nil && ctx.Err() == nil {
+		forEach(opts.Jobs, dirs, func(j *job) {
+			path, added, err := addPlaceholder(j.path, opts.Placeholder)
+			if added || err != nil {
+				j.placeholder, j.placeholderErr = path, err
+			}
+		})
+	}
+
+	emit(root, jobs, opts, report)
+	if err := firstError(root, jobs); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// undoAfter removes the paths created by jobs that come after the first
+// failure in tree order, deepest first, and drops their events. Like
+// Rollback, it keeps directories that are not empty, and paths that
+// cannot be removed keep their event.
+func undoAfter(jobs map[*tree.Node]*job, first int) {
+	var later []*job
+	for _, j := range jobs {
+		if j.index > first && j.kind == Created {
+			later = append(later, j)
+		}
+	}
+	sort.Slice(later, func(a, b int) bool { return later[a].index > later[b].index })
+	for _, j := range later {
+		if err := os.Remove(j.path); err == nil || os.IsNotExist(err) {
+			j.kind, j.err = "", nil
+		}
+	}
+}
+
+// run creates the job's path, or records why it is skipped
+func (j *job) run(opts Options) {
+	if kind, reason := skipNode(j.node, opts, j.depth); kind != "" {
+		j.kind, j.err = kind, reason
+		return
+	}
+	if j.node.IsDir {
+		j.kind, j.err = makeDir(j.path)
+	} else {
+		j.kind, j.err = makeFile(j.node, j.path, opts)
+	}
+}
+
+// emit records the events of the finished jobs in tree order
+func emit(root *tree.Node, jobs map[*tree.Node]*job, opts Options, report *Report) {
+	root.Walk(func(node *tree.Node, _ int) error {
+		j := jobs[node]
+		if j == nil || j.kind == "" {
+			return tree.SkipSubtree
+		}
+		if j.quiet {
+			return nil
+		}
+		report.record(opts.Observer, j.kind, j.path, j.node, j.node.IsDir, j.err)
+		return nil
+	}, func(node *tree.Node, _ int) error {
+		j := jobs[node]
+		if j.placeholderErr != nil {
+			report.record(opts.Observer, Failed, j.placeholder, nil, false, j.placeholderErr)
+		} else if j.placeholder != "" {
+			report.record(opts.Observer, Placeholder, j.placeholder, nil, false, nil)
+		}
+		return nil
+	})
+}
+
+// firstError returns the first error in tree order
+func firstError(root *tree.Node, jobs map[*tree.Node]*job) error {
+	return root.Walk(func(node *tree.Node, _ int) error {
+		j := jobs[node]
+		if j == nil {
+			return tree.SkipSubtree
+		}
+		if j.kind == Failed {
+			return j.err
+		}
+		return nil
+	}, func(node *tree.Node, _ int) error {
+		return jobs[node].placeholderErr
+	})
+}
+
+// forEach calls fn for every job using up to workers goroutines
+func forEach(workers int, jobs []*job, fn func(*job)) {
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	queue := make(chan *job)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				fn(j)
+			}
+		}()
+	}
+	for _, j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+}
@@ -0,0 +1,187 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// wideTree returns a tree of dirs directories with files files each, plus
+// a few nodes that are skipped or get a placeholder
+func wideTree(dirs, files int) *tree.Node {
+	root := &tree.Node{Name: "project", IsDir: true}
+	for i := range dirs {
+		dir := &tree.Node{Name: fmt.Sprintf("dir%03d", i), IsDir: true, Level: 1}
+		for j := range files {
+			dir.Children = append(dir.Children, &tree.Node{Name: fmt.Sprintf("file%03d.txt", j), Level: 2, Content: "x"})
+		}
+		root.Children = append(root.Children, dir)
+	}
+	root.Children = append(root.Children,
+		&tree.Node{Name: "bad:name", Level: 1},
+		&tree.Node{Name: "empty", IsDir: true, Level: 1},
+		&tree.Node{Name: "a", IsDir: true, Level: 1, Children: []*tree.Node{
+			{Name: "b", IsDir: true, Level: 2, Children: []*tree.Node{
+				{Name: "too-deep.txt", Level: 3},
+			}},
+		}},
+	)
+	return root
+}
+
+func TestBuild_ParallelMatchesSequential(t *testing.T) {
+	opts := Options{MaxDepth: 2, Placeholder: DefaultPlaceholder}
+
+	t.Chdir(t.TempDir())
+	sequential, err := Build(wideTree(20, 20), opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, jobs := range []int{2, 8, 64} {
+		t.Run(fmt.Sprintf("jobs=%d", jobs), func(t *testing.T) {
+			t.Chdir(t.TempDir())
+
+			var observed []Event
+			opts := opts
+			opts.Jobs = jobs
+			opts.Observer = func(event Event) { observed = append(observed, event) }
+
+			parallel, err := Build(wideTree(20, 20), opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(parallel.Events, sequential.Events) {
+				t.Errorf("Parallel events differ from the sequential build")
+			}
+			if !reflect.DeepEqual(observed, parallel.Events) {
+				t.Errorf("Observer should receive every event in order")
+			}
+			if len(parallel.Files) != 400 || len(parallel.Placeholders) != 2 {
+				t.Errorf("Expected 400 files and 2 placeholders, got %d and %d", len(parallel.Files), len(parallel.Placeholders))
+			}
+			assertFileExists(t, "project/dir019/file019.txt")
+			assertFileExists(t, "project/empty/.gitkeep")
+			assertNotExists(t, "project/a/b/too-deep.txt")
+		})
+	}
+}
+
+func TestBuild_ParallelErrorIsDeterministic(t *testing.T) {
+	for range 10 {
+		t.Chdir(t.TempDir())
+
+		// Directories in the way of the files make both writes fail
+		for _, name := range []string{"one.txt", "two.txt"} {
+			if err := os.MkdirAll(filepath.Join("project", "src", name), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		root := &tree.Node{Name: "project", IsDir: true, Children: []*tree.Node{
+			{Name: "src", IsDir: true, Level: 1, Children: []*tree.Node{
+				{Name: "one.txt", Level: 2},
+				{Name: "two.txt", Level: 2},
+				{Name: "sub", IsDir: true, Level: 2, Children: []*tree.Node{{Name: "never.txt", Level: 3}}},
+			}},
+		}}
+
+		report, err := Build(root, Options{Jobs: 4})
+		if err == nil {
+			t.Fatal("Expected an error")
+		}
+		var pathErr *os.PathError
+		if !errors.As(err, &pathErr) || filepath.Base(pathErr.Path) != "one.txt" {
+			t.Fatalf("Expected the error for one.txt, got %v", err)
+		}
+		if counts := report.Counts(); counts[Failed] == 0 {
+			t.Errorf("Expected the failure in the report, got %v", counts)
+		}
+		assertNotExists(t, "project/src/sub")
+	}
+}
+
+func TestBuild_ParallelStopsAtFailure(t *testing.T) {
+	// A file in the way of b/ fails the build partway through level 1.
+	// The sequential build completes a/ and creates nothing after b/.
+	tree := func() *tree.Node {
+		root := &tree.Node{Name: "project", IsDir: true}
+		a := &tree.Node{Name: "a", IsDir: true, Level: 1}
+		for i := range 5 {
+			a.Children = append(a.Children, &tree.Node{Name: fmt.Sprintf("deep%d", i), IsDir: true, Level: 2, Children: []*tree.Node{
+				{Name: "file.txt", Level: 3},
+			}})
+		}
+		root.Children = append(root.Children, a, &tree.Node{Name: "b", IsDir: true, Level: 1})
+		for i := range 20 {
+			root.Children = append(root.Children, &tree.Node{Name: fmt.Sprintf("later%02d", i), IsDir: true, Level: 1, Children: []*tree.Node{
+				{Name: "file.txt", Level: 2},
+			}})
+		}
+		return root
+	}
+	build := func(jobs int) []string {
+		t.Chdir(t.TempDir())
+		if err := os.MkdirAll("project", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join("project", "b"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Build(tree(), Options{Jobs: jobs}); err == nil {
+			t.Fatal("Expected an error")
+		}
+		return listPaths(t, "project")
+	}
+
+	sequential := build(1)
+	if len(sequential) != 12 {
+		t.Fatalf("Expected a/ with 5 subtrees and b, got %v", sequential)
+	}
+	for range 10 {
+		for _, jobs := range []int{2, 8} {
+			if parallel := build(jobs); !reflect.DeepEqual(parallel, sequential) {
+				t.Fatalf("With %d jobs, expected %v, got %v", jobs, sequential, parallel)
+			}
+		}
+	}
+}
+
+// listPaths returns the slash-separated paths under dir, in walk order
+func listPaths(t *testing.T, dir string) []string {
+	t.Helper()
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, _ os.DirEntry, err error) error {
+		if err == nil && path != dir {
+			paths = append(paths, filepath.ToSlash(path))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func BenchmarkBuild(b *testing.B) {
+	root := wideTree(100, 100)
+	for _, jobs := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			base := b.TempDir()
+			for i := range b.N {
+				dir := filepath.Join(base, fmt.Sprint(i))
+				if err := os.Mkdir(dir, 0755); err != nil {
+					b.Fatal(err)
+				}
+				b.Chdir(dir)
+				if _, err := Build(root, Options{Jobs: jobs}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
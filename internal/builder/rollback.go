@@ -0,0 +1,39 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Rollback removes the paths that a build reported as Created or
+// Placeholder, newest first. Paths that existed before the build are kept,
+// and so are created directories that are not empty once their contents
+// from the build are gone. It returns the number of paths removed and the
+// first error.
+func Rollback(events []Event) (int, error) {
+	removed := 0
+	var firstErr error
+	for i := len(events) - 1; i >= 0; i-- {
+		event := events[i]
+		if event.Kind != Created && event.Kind != Placeholder {
+			continue
+		}
+
+		path := filepath.FromSlash(event.Path)
+		if event.IsDir {
+			entries, err := os.ReadDir(path)
+			if err == nil && len(entries) > 0 {
+				continue
+			}
+		}
+		err := os.Remove(path)
+		switch {
+		case err == nil:
+			removed++
+		case os.IsNotExist(err):
+		case firstErr == nil:
+			firstErr = err
+		}
+	}
+	return removed, firstErr
+}
@@ -0,0 +1,50 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neomen/buildtree/internal/parser"
+)
+
+func TestRollback(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	// Paths that existed before the build must survive the rollback
+	if err := os.MkdirAll(filepath.Join("app", "old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("app", "old", "keep.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := parser.ParseInput("app/\n├── old/\n│   └── new.txt\n├── src/\n│   └── main.go\n└── logs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := Build(root, Options{Placeholder: DefaultPlaceholder})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Something added after the build keeps its directory
+	if err := os.WriteFile(filepath.Join("app", "src", "extra.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Rollback(report.Events)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if removed != 4 {
+		t.Errorf("Expected 4 paths removed, got %d", removed)
+	}
+
+	for _, path := range []string{"app/old/new.txt", "app/src/main.go", "app/logs"} {
+		assertNotExists(t, filepath.FromSlash(path))
+	}
+	for _, path := range []string{"app/old/keep.txt", "app/src/extra.go"} {
+		assertFileExists(t, filepath.FromSlash(path))
+	}
+}
@@ -0,0 +1,127 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/internal/validator"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// openDir is a directory that may still receive children in a streamed build
+type openDir struct {
+	node  *tree.Node
+	path  string
+	depth int
+	quiet bool // The root under Options.NoRoot, which is not built
+}
+
+// BuildStream creates the structure read by a Scanner node by node, so
+// that memory use depends on the depth of the tree rather than its size.
+// The report only holds counts; per-path events go to Options.Observer.
+// Directives are not resolved, and Options.Jobs is ignored. Once ctx is
+// done the build stops before the next node and returns ctx.Err().
+// Top-level nodes after the first are built next to it, and the report
+// root becomes tree.CurrentDir.
+func BuildStream(ctx context.Context, s *parser.Scanner, opts Options) (*Report, error) {
+	if opts.MaxDepth < 0 {
+		opts.MaxDepth = 0
+	}
+	if err := validatePlaceholder(opts.Placeholder); err != nil {
+		return nil, err
+	}
+	if err := makeOutputDir(opts.Dir); err != nil {
+		return nil, err
+	}
+
+	report := &Report{countsOnly: true}
+	closeDir := func(dir openDir) error {
+		if opts.Placeholder == "" || dir.quiet {
+			return nil
+		}
+		return keepEmpty(dir.path, opts, report)
+	}
+
+	var stack []openDir
+	for s.Scan() {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		node, parent := s.Node(), s.Parent()
+
+		path, depth := filepath.Join(opts.Dir, node.Name), 0
+		first := report.Root == ""
+		if parent == nil && first && (opts.NoRoot || node.Name == tree.CurrentDir) {
+			report.Root = tree.CurrentDir
+			stack = append(stack, openDir{node: node, path: opts.Dir, quiet: true})
+			continue
+		}
+		if parent == nil && first {
+			if err := validator.Validate(node.Name); err != nil {
+				return nil, fmt.Errorf("%w: '%s' (%v)", ErrInvalidRoot, node.Name, err)
+			}
+			report.Root = node.Name
+		} else if parent == nil {
+			// Another top-level node: the trees before it are complete
+			for len(stack) > 0 {
+				if err := closeDir(stack[len(stack)-1]); err != nil {
+					return report, err
+				}
+				stack = stack[:len(stack)-1]
+			}
+			report.Root = tree.CurrentDir
+		} else {
+			// Nodes under a skipped directory are skipped with it
+			i := len(stack) - 1
+			for i >= 0 && stack[i].node != parent {
+				i--
+			}
+			if i < 0 {
+				continue
+			}
+
+			// Directories opened since the parent are complete
+			for len(stack) > i+1 {
+				if err := closeDir(stack[len(stack)-1]); err != nil {
+					return report, err
+				}
+				stack = stack[:len(stack)-1]
+			}
+			path = filepath.Join(stack[i].path, node.Name)
+			depth = stack[i].depth + 1
+		}
+
+		if kind, reason := skipNode(node, opts, depth); kind != "" {
+			report.record(opts.Observer, kind, path, node, node.IsDir, reason)
+			continue
+		}
+
+		if node.IsDir {
+			outcome, err := makeDir(path)
+			report.record(opts.Observer, outcome, path, node, true, err)
+			if err != nil {
+				return report, err
+			}
+			stack = append(stack, openDir{node: node, path: path, depth: depth})
+		} else {
+			outcome, err := makeFile(node, path, opts)
+			report.record(opts.Observer, outcome, path, node, false, err)
+			if err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return report, err
+	}
+
+	for len(stack) > 0 {
+		if err := closeDir(stack[len(stack)-1]); err != nil {
+			return report, err
+		}
+		stack = stack[:len(stack)-1]
+	}
+	return report, nil
+}
@@ -0,0 +1,132 @@
+package builder
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neomen/buildtree/internal/parser"
+)
+
+const streamSpec = `project/
+├── src/
+│   ├── main.go
+│   └── deep/
+│       └── deeper/
+│           └── too-deep.txt
+├── bad:dir/
+│   └── hidden.txt
+├── logs/
+└── README.md`
+
+func TestBuildStream_MatchesBuild(t *testing.T) {
+	opts := Options{MaxDepth: 3, Placeholder: DefaultPlaceholder}
+
+	t.Chdir(t.TempDir())
+	root, err := parser.ParseInput(streamSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := Build(root, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	t.Chdir(t.TempDir())
+	var events []Event
+	opts.Observer = func(event Event) {
+		event.Node = nil
+		events = append(events, event)
+	}
+	report, err := BuildStream(context.Background(), parser.NewScanner(strings.NewReader(streamSpec)), opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var want []Event
+	for _, event := range expected.Events {
+		event.Node = nil
+		want = append(want, event)
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("Streamed events differ.\n got %+v\nwant %+v", events, want)
+	}
+	if !reflect.DeepEqual(report.Counts(), expected.Counts()) {
+		t.Errorf("Expected counts %v, got %v", expected.Counts(), report.Counts())
+	}
+	if len(report.Events) != 0 || len(report.Files) != 0 {
+		t.Error("Streamed reports should only hold counts")
+	}
+
+	assertFileExists(t, "project/src/main.go")
+	assertFileExists(t, "project/logs/.gitkeep")
+	assertNotExists(t, "project/bad:dir")
+	assertNotExists(t, "project/src/deep/deeper/too-deep.txt")
+}
+
+func TestBuildStream_SeveralRoots(t *testing.T) {
+	spec := "app/\n└── main.go\nlib/\nREADME.md"
+
+	t.Chdir(t.TempDir())
+	root, err := parser.ParseInput(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := Build(root, Options{Placeholder: DefaultPlaceholder})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	t.Chdir(t.TempDir())
+	var paths []string
+	report, err := BuildStream(context.Background(), parser.NewScanner(strings.NewReader(spec)), Options{Placeholder: DefaultPlaceholder, Observer: func(event Event) {
+		paths = append(paths, event.Path)
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var want []string
+	for _, event := range expected.Events {
+		want = append(want, event.Path)
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected events for %v, got %v", want, paths)
+	}
+	if report.Root != expected.Root {
+		t.Errorf("Expected root %q, got %q", expected.Root, report.Root)
+	}
+	assertFileExists(t, "app/main.go")
+	assertFileExists(t, "lib/.gitkeep")
+	assertFileExists(t, "README.md")
+}
+
+func TestBuildStream_InvalidRoot(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if _, err := BuildStream(context.Background(), parser.NewScanner(strings.NewReader("../up/\n└── x.txt")), Options{}); err == nil {
+		t.Error("Expected error for invalid root name")
+	}
+	if _, err := BuildStream(context.Background(), parser.NewScanner(strings.NewReader("")), Options{}); err == nil {
+		t.Error("Expected error for empty input")
+	}
+}
+
+func TestBuildStream_Canceled(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var created []string
+	opts := Options{Observer: func(event Event) {
+		created = append(created, event.Path)
+		cancel()
+	}}
+	_, err := BuildStream(ctx, parser.NewScanner(strings.NewReader(streamSpec)), opts)
+	if err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if !reflect.DeepEqual(created, []string{"project"}) {
+		t.Errorf("Expected the build to stop after the root, got %v", created)
+	}
+}
@@ -0,0 +1,210 @@
+package diff
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/neomen/buildtree/internal/glob"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// Kind describes how a path differs between the spec and the filesystem
+type Kind string
+
+const (
+	Missing      Kind = "missing"  // In the spec but not on disk
+	Extra        Kind = "extra"    // On disk but not in the spec
+	TypeMismatch Kind = "mismatch" // Present in both with different types
+)
+
+// EntryType is the type of a path in the spec or on disk
+type EntryType string
+
+const (
+	File    EntryType = "file"
+	Dir     EntryType = "dir"
+	Symlink EntryType = "symlink"
+)
+
+// Change is a single difference between the spec and the filesystem
+type Change struct {
+	Path string     `json:"path"` // Slash-separated, relative to the root
+	Kind Kind       `json:"kind"`
+	Want EntryType  `json:"want,omitempty"` // Type in the spec
+	Got  EntryType  `json:"got,omitempty"`  // Type on disk
+	Node *tree.Node `json:"-"`              // Spec node, unless the path is extra
+	// Spec node of the directory holding an extra path
+	Parent *tree.Node `json:"-"`
+}
+
+// Options controls which differences are reported
+type Options struct {
+	Ignore []string // Glob patterns of paths on disk to leave out of the comparison
+}
+
+// Compare reports the differences between the spec tree and the directory
+// its root corresponds to. Children of missing directories are reported as
+// missing too, while only the top of an extra subtree is reported. Spec
+// names containing glob metacharacters match any number of entries, but
+// never one a sibling names literally: with main.go and *.go in the same
+// directory, *.go needs at least one .go file besides main.go.
+func Compare(spec *tree.Node, dir string, opts Options) ([]Change, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []Change{{Path: ".", Kind: TypeMismatch, Want: Dir, Got: File, Node: spec}}, nil
+	}
+
+	var changes []Change
+	if err := compareDir(spec, dir, "", opts, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func compareDir(node *tree.Node, dir, rel string, opts Options, changes *[]Change) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		seen[entry.Name()] = true
+	}
+
+	claimed := make(map[string]bool, len(node.Children))
+	var patterns []*tree.Node
+	for _, child := range node.Children {
+		if glob.HasMeta(child.Name) {
+			patterns = append(patterns, child)
+			continue
+		}
+		claimed[child.Name] = true
+		if err := compareEntry(child, child.Name, dir, rel, seen, opts, changes); err != nil {
+			return err
+		}
+	}
+
+	// Wildcard nodes apply to every entry of the same type that is not named
+	// literally in the spec, and must match at least one of them
+	literal := make(map[string]bool, len(claimed))
+	for name := range claimed {
+		literal[name] = true
+	}
+	for _, pattern := range patterns {
+		matched := false
+		for _, entry := range entries {
+			name := entry.Name()
+			if literal[name] || entry.IsDir() != pattern.IsDir || entry.Type()&os.ModeSymlink != 0 {
+				continue
+			}
+			if ok, _ := path.Match(pattern.Name, name); !ok {
+				continue
+			}
+			matched = true
+			claimed[name] = true
+			if err := compareEntry(pattern, name, dir, rel, seen, opts, changes); err != nil {
+				return err
+			}
+		}
+		if !matched {
+			addMissing(pattern, path.Join(rel, pattern.Name), changes)
+		}
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !claimed[entry.Name()] {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		childRel := path.Join(rel, name)
+		if isIgnored(opts.Ignore, name, childRel) {
+			continue
+		}
+		got, err := entryType(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		*changes = append(*changes, Change{Path: childRel, Kind: Extra, Got: got, Parent: node})
+	}
+
+	return nil
+}
+
+// compareEntry checks the entry called name in dir against a spec node
+func compareEntry(node *tree.Node, name, dir, rel string, seen map[string]bool, opts Options, changes *[]Change) error {
+	childRel := path.Join(rel, name)
+	want := typeOf(node)
+
+	if !seen[name] {
+		addMissing(node, childRel, changes)
+		return nil
+	}
+
+	got, err := entryType(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	if got != want {
+		*changes = append(*changes, Change{Path: childRel, Kind: TypeMismatch, Want: want, Got: got, Node: node})
+		return nil
+	}
+
+	if node.IsDir {
+		return compareDir(node, filepath.Join(dir, name), childRel, opts, changes)
+	}
+	return nil
+}
+
+func addMissing(node *tree.Node, rel string, changes *[]Change) {
+	*changes = append(*changes, Change{Path: rel, Kind: Missing, Want: typeOf(node), Node: node})
+	for _, child := range node.Children {
+		addMissing(child, path.Join(rel, child.Name), changes)
+	}
+}
+
+func typeOf(node *tree.Node) EntryType {
+	if node.IsDir {
+		return Dir
+	}
+	return File
+}
+
+// entryType inspects a path without following symbolic links
+func entryType(p string) (EntryType, error) {
+	info, err := os.Lstat(p)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return Symlink, nil
+	case info.IsDir():
+		return Dir, nil
+	default:
+		return File, nil
+	}
+}
+
+// isIgnored checks the base name, or the relative path for patterns containing a slash
+func isIgnored(patterns []string, name, rel string) bool {
+	for _, pattern := range patterns {
+		target := name
+		if path.Dir(pattern) != "." {
+			target = rel
+		}
+		if glob.Match(pattern, target) {
+			return true
+		}
+	}
+	return false
+}
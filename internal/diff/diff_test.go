@@ -0,0 +1,199 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+func specTree() *tree.Node {
+	return &tree.Node{
+		Name:  "project",
+		IsDir: true,
+		Children: []*tree.Node{
+			{
+				Name:  "src",
+				IsDir: true,
+				Level: 1,
+				Children: []*tree.Node{
+					{Name: "main.go", Level: 2},
+				},
+			},
+			{
+				Name:  "docs",
+				IsDir: true,
+				Level: 1,
+				Children: []*tree.Node{
+					{Name: "index.md", Level: 2},
+				},
+			},
+			{Name: "config", IsDir: true, Level: 1},
+			{Name: "go.mod", Level: 1},
+			{Name: "link.txt", Level: 1},
+		},
+	}
+}
+
+func TestCompare_Match(t *testing.T) {
+	dir := t.TempDir()
+	mkdirs(t, dir, "src", "docs", "config")
+	touch(t, dir, "src/main.go", "docs/index.md", "go.mod", "link.txt")
+
+	changes, err := Compare(specTree(), dir, Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Expected no changes, got %+v", changes)
+	}
+}
+
+func TestCompare_Differences(t *testing.T) {
+	dir := t.TempDir()
+	mkdirs(t, dir, "src", "build/out", "go.mod")
+	touch(t, dir, "src/main.go", "src/extra.go", "config", "notes.txt")
+	if err := os.Symlink("go.mod", filepath.Join(dir, "link.txt")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	changes, err := Compare(specTree(), dir, Options{Ignore: []string{"*.txt"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []Change{
+		{Path: "src/extra.go", Kind: Extra, Got: File},
+		{Path: "docs", Kind: Missing, Want: Dir},
+		{Path: "docs/index.md", Kind: Missing, Want: File},
+		{Path: "config", Kind: TypeMismatch, Want: Dir, Got: File},
+		{Path: "go.mod", Kind: TypeMismatch, Want: File, Got: Dir},
+		{Path: "link.txt", Kind: TypeMismatch, Want: File, Got: Symlink},
+		{Path: "build", Kind: Extra, Got: Dir},
+	}
+
+	for i := range changes {
+		changes[i].Node = nil
+		changes[i].Parent = nil
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Unexpected changes.\nExpected: %+v\nGot:      %+v", expected, changes)
+	}
+}
+
+func TestCompare_RootIsFile(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "project")
+
+	changes, err := Compare(specTree(), filepath.Join(dir, "project"), Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Kind != TypeMismatch || changes[0].Path != "." {
+		t.Errorf("Expected a root type mismatch, got %+v", changes)
+	}
+
+	if _, err := Compare(specTree(), filepath.Join(dir, "missing"), Options{}); err == nil {
+		t.Error("Expected error for missing directory")
+	}
+}
+
+func TestCompare_Wildcards(t *testing.T) {
+	spec := &tree.Node{
+		Name:  "services",
+		IsDir: true,
+		Children: []*tree.Node{
+			{
+				Name:  "*",
+				IsDir: true,
+				Level: 1,
+				Children: []*tree.Node{
+					{Name: "main.go", Level: 2},
+				},
+			},
+			{Name: "README.md", Level: 1},
+			{Name: "*.yaml", Level: 1},
+		},
+	}
+
+	dir := t.TempDir()
+	mkdirs(t, dir, "api", "worker")
+	touch(t, dir, "api/main.go", "README.md", "notes.txt")
+
+	changes, err := Compare(spec, dir, Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var paths []string
+	for _, change := range changes {
+		paths = append(paths, string(change.Kind)+" "+change.Path)
+	}
+	expected := []string{"missing worker/main.go", "missing *.yaml", "extra notes.txt"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+	if changes[0].Node != spec.Children[0].Children[0] {
+		t.Error("Missing change should point at the spec node")
+	}
+	if changes[2].Parent != spec {
+		t.Error("Extra change should point at the parent spec node")
+	}
+}
+
+func TestCompare_WildcardSkipsLiteralSiblings(t *testing.T) {
+	spec := &tree.Node{
+		Name:  "src",
+		IsDir: true,
+		Children: []*tree.Node{
+			{Name: "main.go", Level: 1},
+			{Name: "*.go", Level: 1},
+		},
+	}
+
+	tests := []struct {
+		files    []string
+		expected []string
+	}{
+		{[]string{"main.go", "util.go"}, nil},
+		{[]string{"main.go"}, []string{"missing *.go"}},
+		{[]string{"util.go"}, []string{"missing main.go"}},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		touch(t, dir, tt.files...)
+
+		changes, err := Compare(spec, dir, Options{})
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.files, err)
+		}
+		var paths []string
+		for _, change := range changes {
+			paths = append(paths, string(change.Kind)+" "+change.Path)
+		}
+		if !reflect.DeepEqual(paths, tt.expected) {
+			t.Errorf("%v: expected %v, got %v", tt.files, tt.expected, paths)
+		}
+	}
+}
+
+func mkdirs(t *testing.T, dir string, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.FromSlash(p)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func touch(t *testing.T, dir string, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(p)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package diff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ANSI colors used for each kind of change
+var colors = map[Kind]string{
+	Missing:      "\033[31m",
+	Extra:        "\033[32m",
+	TypeMismatch: "\033[33m",
+}
+
+const colorReset = "\033[0m"
+
+// markers prefix each line of a rendered diff
+var markers = map[Kind]string{
+	"":           "  ",
+	Missing:      "- ",
+	Extra:        "+ ",
+	TypeMismatch: "~ ",
+}
+
+// changeNode is a path in the rendered diff; context nodes have no kind
+type changeNode struct {
+	name     string
+	isDir    bool
+	change   *Change
+	children []*changeNode
+}
+
+// Render writes the changes as a tree under the root name. Only changed
+// paths and their parent directories are shown.
+func Render(w io.Writer, rootName string, changes []Change, color bool) error {
+	root := &changeNode{name: rootName, isDir: true}
+	for i := range changes {
+		insert(root, &changes[i])
+	}
+
+	bw := bufio.NewWriter(w)
+	writeNode(bw, root, "", "", color)
+	return bw.Flush()
+}
+
+func insert(root *changeNode, change *Change) {
+	if change.Path == "." {
+		root.change = change
+		return
+	}
+
+	node := root
+	segments := strings.Split(change.Path, "/")
+	for i, segment := range segments {
+		var child *changeNode
+		for _, existing := range node.children {
+			if existing.name == segment {
+				child = existing
+				break
+			}
+		}
+		if child == nil {
+			child = &changeNode{name: segment, isDir: true}
+			node.children = append(node.children, child)
+		}
+		if i == len(segments)-1 {
+			child.change = change
+			child.isDir = change.Want == Dir || (change.Want == "" && change.Got == Dir)
+		}
+		node = child
+	}
+}
+
+func writeNode(w *bufio.Writer, node *changeNode, linePrefix, childPrefix string, color bool) {
+	kind := Kind("")
+	if node.change != nil {
+		kind = node.change.Kind
+	}
+
+	line := markers[kind] + linePrefix + node.name
+	if node.isDir {
+		line += "/"
+	}
+	if kind == TypeMismatch {
+		line += fmt.Sprintf(" (expected %s, found %s)", node.change.Want, node.change.Got)
+	}
+
+	if color && kind != "" {
+		line = colors[kind] + line + colorReset
+	}
+	w.WriteString(line)
+	w.WriteString("\n")
+
+	for i, child := range node.children {
+		branch, indent := "├── ", "│   "
+		if i == len(node.children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		writeNode(w, child, childPrefix+branch, childPrefix+indent, color)
+	}
+}
@@ -0,0 +1,47 @@
+package diff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	changes := []Change{
+		{Path: "src/extra.go", Kind: Extra, Got: File},
+		{Path: "docs", Kind: Missing, Want: Dir},
+		{Path: "docs/index.md", Kind: Missing, Want: File},
+		{Path: "config", Kind: TypeMismatch, Want: Dir, Got: File},
+	}
+
+	expected := `  project/
+  ├── src/
++ │   └── extra.go
+- ├── docs/
+- │   └── index.md
+~ └── config/ (expected dir, found file)
+`
+
+	var buf bytes.Buffer
+	if err := Render(&buf, "project", changes, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != expected {
+		t.Errorf("Unexpected output.\nExpected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
+
+func TestRender_Color(t *testing.T) {
+	changes := []Change{{Path: "main.go", Kind: Missing, Want: File}}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, "project", changes, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), colors[Missing]+"- └── main.go"+colorReset) {
+		t.Errorf("Expected colored missing line, got %q", buf.String())
+	}
+	if strings.HasPrefix(buf.String(), "\033") {
+		t.Error("Context lines should not be colored")
+	}
+}
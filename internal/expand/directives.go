@@ -0,0 +1,158 @@
+package expand
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// falsy lists the rendered condition values that count as false, so that
+// "--set docker=false" disables a block like an unset variable does
+var falsy = map[string]bool{"": true, "false": true, "0": true, "no": true, "off": true, "<no value>": true}
+
+// expandIf returns the lines nested under an @if block when its condition
+// holds. Conditions are template pipelines such as ".docker" or
+// `eq .db "postgres"`; a bare name stands for the variable of that name.
+// A condition using undefined variables is false.
+func expandIf(node *tree.Node, values Values, shift int, state *expansion) ([]*tree.Node, error) {
+	tmpl, err := parseCondition(node)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range fields(tmpl) {
+		if _, ok := values[name]; !ok {
+			return nil, nil
+		}
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, map[string]any(values)); err != nil {
+		return nil, fmt.Errorf("line %d: @if %s: %w", node.Line, node.Name, err)
+	}
+	if falsy[strings.TrimSpace(out.String())] {
+		return nil, nil
+	}
+	return expandChildren(node, values, shift+1, state)
+}
+
+// expandEach returns the lines nested under an "@each item in list" block
+// once for every element of the list, with the element bound to item.
+// Lists come from YAML sequences or comma-separated strings.
+func expandEach(node *tree.Node, values Values, shift int, state *expansion) ([]*tree.Node, error) {
+	item, list, err := parseEach(node)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := values[list[0]]
+	if !ok {
+		if state.dryRun {
+			state.undefined[list[0]] = true
+			return nil, nil
+		}
+		return nil, fmt.Errorf("line %d: @each: undefined variable %s", node.Line, list[0])
+	}
+	for _, key := range list[1:] {
+		mapping, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("line %d: @each: %s is not a mapping", node.Line, node.Name)
+		}
+		value = mapping[key]
+	}
+
+	items, err := listItems(value)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: @each %s: %w", node.Line, node.Name, err)
+	}
+
+	var expanded []*tree.Node
+	for _, element := range items {
+		scope := make(Values, len(values)+1)
+		scope.Merge(values)
+		scope[item] = element
+
+		children, err := expandChildren(node, scope, shift+1, state)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, children...)
+	}
+	return expanded, nil
+}
+
+// parseCondition parses the expression of an @if block
+func parseCondition(node *tree.Node) (*template.Template, error) {
+	expr := strings.TrimSpace(node.Name)
+	if expr == "" {
+		return nil, fmt.Errorf("line %d: @if: missing condition", node.Line)
+	}
+	if isIdentifier(expr) {
+		expr = "." + expr
+	}
+
+	tmpl, err := template.New(node.Name).Parse("{{ " + expr + " }}")
+	if err != nil {
+		return nil, fmt.Errorf("line %d: @if %s: %w", node.Line, node.Name, err)
+	}
+	return tmpl, nil
+}
+
+// parseEach splits "item in list" into the item name and the dotted path
+// of the list, e.g. "svc in project.services"
+func parseEach(node *tree.Node) (item string, list []string, err error) {
+	words := strings.Fields(node.Name)
+	if len(words) != 3 || words[1] != "in" {
+		return "", nil, fmt.Errorf("line %d: @each %s: want 'item in list'", node.Line, node.Name)
+	}
+
+	item = strings.TrimPrefix(words[0], ".")
+	list = strings.Split(strings.TrimPrefix(words[2], "."), ".")
+	if !isIdentifier(item) {
+		return "", nil, fmt.Errorf("line %d: @each: invalid item name %q", node.Line, words[0])
+	}
+	for _, key := range list {
+		if !isIdentifier(key) {
+			return "", nil, fmt.Errorf("line %d: @each: invalid list %q", node.Line, words[2])
+		}
+	}
+	return item, list, nil
+}
+
+// listItems returns the elements of a list value
+func listItems(value any) ([]any, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return v, nil
+	case []string:
+		items := make([]any, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items, nil
+	case string:
+		var items []any
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("%v is not a list", value)
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r != '_' && !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && (i == 0 || !('0' <= r && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
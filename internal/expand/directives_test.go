@@ -0,0 +1,180 @@
+package expand
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+const blocksSpec = `{{ .Name }}/
+├── @if docker
+│   ├── Dockerfile
+│   └── docker/
+│       └── {{ .Image }}.env
+├── cmd/
+│   └── @each svc in services
+│       └── {{ .svc }}/
+│           └── main.go
+└── go.mod`
+
+func TestExpand_Blocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   Values
+		expected []string
+	}{
+		{
+			name:     "condition and list",
+			values:   Values{"Name": "app", "docker": "true", "Image": "base", "services": []any{"api", "worker"}},
+			expected: []string{"app/", "Dockerfile@1", "docker/@1", "base.env@2", "cmd/@1", "api/@2", "main.go@3", "worker/@2", "main.go@3", "go.mod@1"},
+		},
+		{
+			name:     "false condition and comma list",
+			values:   Values{"Name": "app", "docker": "false", "services": "api, worker"},
+			expected: []string{"app/", "cmd/@1", "api/@2", "main.go@3", "worker/@2", "main.go@3", "go.mod@1"},
+		},
+		{
+			name:     "undefined condition and empty list",
+			values:   Values{"Name": "app", "services": []any{}},
+			expected: []string{"app/", "cmd/@1", "go.mod@1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expanded, err := Expand(parseSpec(t, blocksSpec), tt.values)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := flatten(expanded); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExpand_Conditions(t *testing.T) {
+	spec := `project/
+├── @if eq .db "postgres"
+│   └── migrations/
+└── @if not .minimal
+    └── docs/`
+
+	expanded, err := Expand(parseSpec(t, spec), Values{"db": "postgres", "minimal": true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := flatten(expanded); !reflect.DeepEqual(got, []string{"project/", "migrations/@1"}) {
+		t.Errorf("Unexpected tree %v", got)
+	}
+}
+
+func TestExpand_NestedEach(t *testing.T) {
+	spec := `project/
+└── @each svc in services
+    ├── {{ .svc.name }}/
+    └── @each port in svc.ports
+        └── {{ .svc.name }}-{{ .port }}.conf`
+
+	values := Values{"services": []any{
+		map[string]any{"name": "api", "ports": []any{80, 443}},
+		map[string]any{"name": "db"},
+	}}
+	expanded, err := Expand(parseSpec(t, spec), values)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"project/", "api/@1", "api-80.conf@1", "api-443.conf@1", "db/@1"}
+	if got := flatten(expanded); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestExpand_BlockErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   string
+		values Values
+		err    string
+	}{
+		{"undefined list", "p/\n└── @each svc in services\n    └── {{ .svc }}", Values{}, "undefined variables: services"},
+		{"not a list", "p/\n└── @each svc in port\n    └── x", Values{"port": 80}, "80 is not a list"},
+		{"bad each syntax", "p/\n└── @each services\n    └── x", Values{}, "line 2: @each services: want 'item in list'"},
+		{"bad condition", "p/\n└── @if eq (\n    └── x", Values{}, "line 2: @if eq ("},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Expand(parseSpec(t, tt.spec), tt.values)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestExpand_UnreachableVariables(t *testing.T) {
+	spec := `project/
+├── @if docker
+│   └── {{ .Image }}.env
+└── {{ .Name }}.go`
+
+	_, err := Expand(parseSpec(t, spec), Values{})
+	var undefined *UndefinedError
+	if !errors.As(err, &undefined) || !reflect.DeepEqual(undefined.Names, []string{"Name"}) {
+		t.Errorf("Only variables of the expanded tree should be required, got %v", err)
+	}
+}
+
+func TestVariablesAndConditions(t *testing.T) {
+	root := parseSpec(t, blocksSpec)
+
+	names, err := Variables(root)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"Image", "Name", "docker", "services"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected variables %v, got %v", expected, names)
+	}
+
+	conditions, err := Conditions(root)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"docker"}; !reflect.DeepEqual(conditions, expected) {
+		t.Errorf("Expected conditions %v, got %v", expected, conditions)
+	}
+}
+
+func parseSpec(t *testing.T, spec string) *tree.Node {
+	t.Helper()
+	root, err := parser.ParseInput(spec)
+	if err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+	return root
+}
+
+// flatten lists the tree in order as "name/@level" for directories and
+// "name@level" for files, with the root listed without a level
+func flatten(root *tree.Node) []string {
+	list := []string{root.Name + "/"}
+	var walk func(nodes []*tree.Node)
+	walk = func(nodes []*tree.Node) {
+		for _, node := range nodes {
+			name := node.Name
+			if node.IsDir {
+				name += "/"
+			}
+			list = append(list, name+"@"+strconv.Itoa(node.Level))
+			walk(node.Children)
+		}
+	}
+	walk(root.Children)
+	return list
+}
@@ -0,0 +1,287 @@
+package expand
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/internal/validator"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// UndefinedError lists the variables used in a tree without a value
+type UndefinedError struct {
+	Names []string
+}
+
+func (e *UndefinedError) Error() string {
+	return "undefined variables: " + strings.Join(e.Names, ", ")
+}
+
+// InvalidNameError lists the names that are not valid paths after substitution
+type InvalidNameError struct {
+	Names []string
+}
+
+func (e *InvalidNameError) Error() string {
+	return "invalid names after substitution: " + strings.Join(e.Names, ", ")
+}
+
+// Expand returns a copy of the tree with {{ .Name }} placeholders in node
+// names and contents replaced by values, and @if / @each blocks replaced by
+// the lines they produce. All undefined variables in the resulting tree are
+// reported before anything is substituted, and substituted names must be
+// valid paths.
+func Expand(root *tree.Node, values Values) (*tree.Node, error) {
+	// A dry run finds the variables that the expanded tree actually needs,
+	// so that lines dropped by a false @if do not require values
+	undefined := map[string]bool{}
+	if _, err := expandNode(root, values, 0, &expansion{undefined: undefined, dryRun: true}); err != nil {
+		return nil, err
+	}
+	if len(undefined) > 0 {
+		return nil, &UndefinedError{Names: sortedKeys(undefined)}
+	}
+
+	state := &expansion{}
+	expanded, err := expandNode(root, values, 0, state)
+	if err != nil {
+		return nil, err
+	}
+	if len(state.invalid) > 0 {
+		return nil, &InvalidNameError{Names: state.invalid}
+	}
+	expanded[0].SetParents()
+	return expanded[0], nil
+}
+
+// Variables returns the sorted names of all variables used in the tree,
+// including those in @if conditions and @each lists
+func Variables(root *tree.Node) ([]string, error) {
+	used := map[string]bool{}
+	if err := collectVariables(root, map[string]bool{}, used, nil); err != nil {
+		return nil, err
+	}
+	return sortedKeys(used), nil
+}
+
+// Conditions returns the sorted names of variables used only in @if
+// conditions. They are optional: an undefined condition is false.
+func Conditions(root *tree.Node) ([]string, error) {
+	used := map[string]bool{}
+	conditions := map[string]bool{}
+	if err := collectVariables(root, map[string]bool{}, used, conditions); err != nil {
+		return nil, err
+	}
+	for name := range used {
+		delete(conditions, name)
+	}
+	return sortedKeys(conditions), nil
+}
+
+// expansion carries the state of a single pass over the tree
+type expansion struct {
+	dryRun    bool            // Only record undefined variables
+	undefined map[string]bool // Filled in a dry run
+	invalid   []string
+}
+
+// expandNode expands a node into the nodes that replace it: itself for
+// plain nodes, and the expanded lines nested under @if and @each blocks.
+// shift is the number of enclosing blocks, by which levels are reduced.
+func expandNode(node *tree.Node, values Values, shift int, state *expansion) ([]*tree.Node, error) {
+	switch node.Directive {
+	case parser.DirectiveIf:
+		return expandIf(node, values, shift, state)
+	case parser.DirectiveEach:
+		return expandEach(node, values, shift, state)
+	}
+
+	name, err := execute(node.Name, values, node, state)
+	if err != nil {
+		return nil, err
+	}
+	content, err := execute(node.Content, values, node, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if !state.dryRun && name != node.Name {
+		if err := validator.Validate(name); err != nil {
+			state.invalid = append(state.invalid, fmt.Sprintf("%q (line %d, %v)", name, node.Line, err))
+		}
+	}
+
+	expanded := *node
+	expanded.Name = name
+	expanded.Content = content
+	expanded.Level = node.Level - shift
+
+	children, err := expandChildren(node, values, shift, state)
+	if err != nil {
+		return nil, err
+	}
+	expanded.Children = children
+
+	return []*tree.Node{&expanded}, nil
+}
+
+func expandChildren(node *tree.Node, values Values, shift int, state *expansion) ([]*tree.Node, error) {
+	children := make([]*tree.Node, 0, len(node.Children))
+	for _, child := range node.Children {
+		expanded, err := expandNode(child, values, shift, state)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, expanded...)
+	}
+	return children, nil
+}
+
+// execute renders a single template string. In a dry run the text is only
+// checked for undefined variables.
+func execute(text string, values Values, node *tree.Node, state *expansion) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(node.Name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("line %d: %w", node.Line, err)
+	}
+
+	if state.dryRun {
+		for _, name := range fields(tmpl) {
+			if _, ok := values[name]; !ok {
+				state.undefined[name] = true
+			}
+		}
+		return text, nil
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, map[string]any(values)); err != nil {
+		return "", fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return out.String(), nil
+}
+
+// collectVariables records the variables used in the tree that are not
+// bound by an enclosing @each. Names used in @if conditions are also
+// recorded in conditions, when it is not nil.
+func collectVariables(node *tree.Node, bound, used, conditions map[string]bool) error {
+	record := func(names []string, into map[string]bool) {
+		for _, name := range names {
+			if !bound[name] {
+				into[name] = true
+			}
+		}
+	}
+
+	switch node.Directive {
+	case parser.DirectiveIf:
+		tmpl, err := parseCondition(node)
+		if err != nil {
+			return err
+		}
+		if conditions != nil {
+			record(fields(tmpl), conditions)
+		} else {
+			record(fields(tmpl), used)
+		}
+	case parser.DirectiveEach:
+		item, list, err := parseEach(node)
+		if err != nil {
+			return err
+		}
+		record(list[:1], used)
+		inner := make(map[string]bool, len(bound)+1)
+		for name := range bound {
+			inner[name] = true
+		}
+		inner[item] = true
+		bound = inner
+	default:
+		for _, text := range []string{node.Name, node.Content} {
+			if !strings.Contains(text, "{{") {
+				continue
+			}
+			tmpl, err := template.New(node.Name).Parse(text)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", node.Line, err)
+			}
+			record(fields(tmpl), used)
+		}
+	}
+
+	for _, child := range node.Children {
+		if err := collectVariables(child, bound, used, conditions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fields returns the top-level fields referenced by a parsed template
+func fields(tmpl *template.Template) []string {
+	var names []string
+	for _, t := range tmpl.Templates() {
+		walkFields(t.Tree.Root, func(name string) {
+			names = append(names, name)
+		})
+	}
+	return names
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// walkFields reports the top-level fields referenced on the root data.
+// Bodies of range and with are skipped because they rebind the dot.
+func walkFields(node parse.Node, visit func(name string)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkFields(child, visit)
+		}
+	case *parse.ActionNode:
+		walkFields(n.Pipe, visit)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkFields(cmd, visit)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkFields(arg, visit)
+		}
+	case *parse.FieldNode:
+		visit(n.Ident[0])
+	case *parse.IfNode:
+		walkFields(n.Pipe, visit)
+		walkFields(n.List, visit)
+		walkFields(n.ElseList, visit)
+	case *parse.RangeNode:
+		walkFields(n.Pipe, visit)
+		walkFields(n.ElseList, visit)
+	case *parse.WithNode:
+		walkFields(n.Pipe, visit)
+		walkFields(n.ElseList, visit)
+	case *parse.TemplateNode:
+		walkFields(n.Pipe, visit)
+	}
+}
@@ -0,0 +1,101 @@
+package expand
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+func templateTree() *tree.Node {
+	return &tree.Node{
+		Name:  "{{ .ServiceName }}",
+		IsDir: true,
+		Line:  1,
+		Children: []*tree.Node{
+			{
+				Name:    "{{ .ServiceName }}.go",
+				Level:   1,
+				Line:    2,
+				Content: "package {{ .Package }}\n",
+			},
+			{Name: "README.md", Level: 1, Line: 3},
+		},
+	}
+}
+
+func TestExpand(t *testing.T) {
+	root := templateTree()
+
+	expanded, err := Expand(root, Values{"ServiceName": "billing", "Package": "main"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if expanded.Name != "billing" {
+		t.Errorf("Expected root name 'billing', got '%s'", expanded.Name)
+	}
+	if file := expanded.Children[0]; file.Name != "billing.go" || file.Content != "package main\n" || file.Line != 2 {
+		t.Errorf("Unexpected file node %+v", file)
+	}
+	if expanded.Children[1].Name != "README.md" {
+		t.Error("Plain names should be unchanged")
+	}
+	if root.Name != "{{ .ServiceName }}" {
+		t.Error("The original tree should not be modified")
+	}
+}
+
+func TestExpand_Undefined(t *testing.T) {
+	_, err := Expand(templateTree(), Values{})
+
+	var undefined *UndefinedError
+	if !errors.As(err, &undefined) {
+		t.Fatalf("Expected UndefinedError, got %v", err)
+	}
+	if expected := []string{"Package", "ServiceName"}; !reflect.DeepEqual(undefined.Names, expected) {
+		t.Errorf("Expected %v, got %v", expected, undefined.Names)
+	}
+}
+
+func TestExpand_InvalidName(t *testing.T) {
+	_, err := Expand(templateTree(), Values{"ServiceName": "../escape", "Package": "main"})
+
+	var invalid *InvalidNameError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Expected InvalidNameError, got %v", err)
+	}
+	if len(invalid.Names) != 2 {
+		t.Fatalf("Expected 2 invalid names, got %v", invalid.Names)
+	}
+	if !strings.Contains(invalid.Names[0], "invalid name: dot segment '..' at position 1") {
+		t.Errorf("Expected the reason in %q", invalid.Names[0])
+	}
+}
+
+func TestExpand_TemplateSyntax(t *testing.T) {
+	root := &tree.Node{
+		Name:  "project",
+		IsDir: true,
+		Children: []*tree.Node{
+			{Name: "{{ if .Docker }}Dockerfile{{ else }}Procfile{{ end }}", Level: 1},
+			{Name: "{{ range .Items }}{{ .Missing }}{{ end }}list.txt", Level: 1},
+			{Name: "{{ .Broken", Level: 1, Line: 4},
+		},
+	}
+
+	if _, err := Expand(root, Values{"Docker": true, "Items": []any{}}); err == nil {
+		t.Error("Expected parse error for unterminated action")
+	}
+
+	root.Children = root.Children[:2]
+	expanded, err := Expand(root, Values{"Docker": true, "Items": []any{}})
+	if err != nil {
+		t.Fatalf("Fields inside range bodies should not be required: %v", err)
+	}
+	if expanded.Children[0].Name != "Dockerfile" {
+		t.Errorf("Expected 'Dockerfile', got '%s'", expanded.Children[0].Name)
+	}
+}
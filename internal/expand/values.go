@@ -0,0 +1,61 @@
+package expand
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix marks environment variables that provide template values,
+// e.g. BUILDTREE_VAR_ServiceName sets .ServiceName
+const EnvPrefix = "BUILDTREE_VAR_"
+
+// Values maps variable names to the values substituted into a tree
+type Values map[string]any
+
+// Set assigns a value from a "key=value" string
+func (v Values) Set(assignment string) error {
+	key, value, ok := strings.Cut(assignment, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("invalid assignment %q (want key=value)", assignment)
+	}
+	v[key] = value
+	return nil
+}
+
+// Merge copies all values from other, overriding existing keys
+func (v Values) Merge(other Values) {
+	for key, value := range other {
+		v[key] = value
+	}
+}
+
+// LoadFile reads values from a YAML (or JSON) file with a top-level mapping
+func LoadFile(path string) (Values, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := Values{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// FromEnv collects values from "KEY=value" environment entries carrying EnvPrefix
+func FromEnv(environ []string) Values {
+	values := Values{}
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(key, EnvPrefix) || key == EnvPrefix {
+			continue
+		}
+		values[strings.TrimPrefix(key, EnvPrefix)] = value
+	}
+	return values
+}
@@ -0,0 +1,57 @@
+package expand
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValues_Set(t *testing.T) {
+	values := Values{}
+	if err := values.Set("name=api=v2"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if values["name"] != "api=v2" {
+		t.Errorf("Expected 'api=v2', got %v", values["name"])
+	}
+
+	for _, invalid := range []string{"novalue", "=value"} {
+		if err := values.Set(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(path, []byte("ServiceName: billing\nPort: 8080\nServices:\n  - api\n  - worker\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if values["ServiceName"] != "billing" || values["Port"] != 8080 {
+		t.Errorf("Unexpected values %v", values)
+	}
+	if services, ok := values["Services"].([]any); !ok || len(services) != 2 {
+		t.Errorf("Expected a list of services, got %v", values["Services"])
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestFromEnvAndMerge(t *testing.T) {
+	values := FromEnv([]string{"BUILDTREE_VAR_Name=env", "BUILDTREE_VAR_Port=1", "HOME=/root", "BUILDTREE_VAR_=x"})
+	if len(values) != 2 || values["Name"] != "env" {
+		t.Fatalf("Unexpected values %v", values)
+	}
+
+	values.Merge(Values{"Name": "file"})
+	if values["Name"] != "file" || values["Port"] != "1" {
+		t.Errorf("Unexpected merged values %v", values)
+	}
+}
@@ -0,0 +1,118 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/internal/render"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// Format identifies a textual representation of a tree
+type Format string
+
+const (
+	Tree     Format = "tree"     // Unicode tree diagram
+	ASCII    Format = "ascii"    // ASCII tree diagram
+	Indent   Format = "indent"   // Indented list without glyphs
+	Paths    Format = "paths"    // One slash-separated path per line
+	JSON     Format = "json"     // Nested JSON objects
+	YAML     Format = "yaml"     // Nested YAML mappings
+	Markdown Format = "markdown" // Markdown nested bullet list
+)
+
+// Formats lists all supported formats
+var Formats = []Format{Tree, ASCII, Indent, Paths, JSON, YAML, Markdown}
+
+// indentWidth is the number of columns per level in indented and Markdown lists
+const indentWidth = 2
+
+// Lookup returns the format with the given name
+func Lookup(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+	switch strings.ToLower(name) {
+	case "unicode":
+		return Tree, nil
+	case "yml":
+		return YAML, nil
+	case "md":
+		return Markdown, nil
+	}
+	return "", fmt.Errorf("unknown format %q", name)
+}
+
+// Detect guesses the format of a file from its extension, defaulting to Tree
+func Detect(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON
+	case ".yaml", ".yml":
+		return YAML
+	case ".md", ".markdown":
+		return Markdown
+	}
+	return Tree
+}
+
+// Parse reads a tree written in the given format
+func Parse(f Format, input string) (*tree.Node, error) {
+	root, err := parse(f, input)
+	if err != nil {
+		return nil, err
+	}
+	root.SetParents()
+	return root, nil
+}
+
+func parse(f Format, input string) (*tree.Node, error) {
+	switch f {
+	case Tree, ASCII, Indent:
+		// The diagram parser accepts any glyph set and indentation width
+		return parser.ParseInput(input)
+	case Paths:
+		return parsePaths(input)
+	case JSON:
+		return parseJSON(input)
+	case YAML:
+		return parseYAML(input)
+	case Markdown:
+		return parseMarkdown(input)
+	}
+	return nil, fmt.Errorf("unknown format %q", f)
+}
+
+// Render writes the tree in the given format
+func Render(f Format, w io.Writer, root *tree.Node) error {
+	switch f {
+	case Tree:
+		return render.Tree(w, root, render.Options{Comments: true})
+	case ASCII:
+		return render.Tree(w, root, render.Options{Style: render.StyleASCII, Comments: true})
+	case Indent:
+		return render.Tree(w, root, render.Options{Style: render.StyleIndented, Indent: indentWidth, Comments: true})
+	case Paths:
+		return renderPaths(w, root)
+	case JSON:
+		return renderJSON(w, root)
+	case YAML:
+		return renderYAML(w, root)
+	case Markdown:
+		return renderMarkdown(w, root)
+	}
+	return fmt.Errorf("unknown format %q", f)
+}
+
+// setLevels recomputes node levels from their position in the tree
+func setLevels(node *tree.Node, level int) {
+	node.Level = level
+	for _, child := range node.Children {
+		setLevels(child, level+1)
+	}
+}
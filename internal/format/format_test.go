@@ -0,0 +1,287 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+func sampleTree() *tree.Node {
+	return &tree.Node{
+		Name:    "project",
+		IsDir:   true,
+		Comment: "demo",
+		Children: []*tree.Node{
+			{
+				Name:  "src",
+				IsDir: true,
+				Level: 1,
+				Children: []*tree.Node{
+					{Name: "main.go", Level: 2, Comment: "entry point"},
+					{Name: "empty", IsDir: true, Level: 2},
+				},
+			},
+			{Name: "Makefile", Level: 1},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		format   Format
+		expected string
+	}{
+		{Tree, "project/ # demo\n├── src/\n│   ├── main.go # entry point\n│   └── empty/\n└── Makefile\n"},
+		{ASCII, "project/ # demo\n|-- src/\n|   |-- main.go # entry point\n|   '-- empty/\n'-- Makefile\n"},
+		{Indent, "project/ # demo\n  src/\n    main.go # entry point\n    empty/\n  Makefile\n"},
+		{Paths, "project/\nproject/src/\nproject/src/main.go\nproject/src/empty/\nproject/Makefile\n"},
+		{Markdown, "- project/ # demo\n  - src/\n    - main.go # entry point\n    - empty/\n  - Makefile\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(tt.format, &buf, sampleTree()); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Unexpected output.\nExpected:\n%s\nGot:\n%s", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, f := range Formats {
+		t.Run(string(f), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(f, &buf, sampleTree()); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			root, err := Parse(f, buf.String())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v\nInput:\n%s", err, buf.String())
+			}
+
+			assertSameTree(t, sampleTree(), root, f != Paths)
+		})
+	}
+}
+
+func TestParseJSON_Content(t *testing.T) {
+	input := `{
+  "name": "project",
+  "children": [
+    {"name": "bin/"},
+    {"name": "README.md", "content": "# Hello\n"},
+    {"name": "LICENSE", "type": "file"}
+  ]
+}`
+
+	root, err := Parse(JSON, input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !root.IsDir || len(root.Children) != 3 {
+		t.Fatalf("Unexpected root %+v", root)
+	}
+	if bin := root.Children[0]; bin.Name != "bin" || !bin.IsDir {
+		t.Errorf("bin should be a directory, got %+v", bin)
+	}
+	if readme := root.Children[1]; readme.IsDir || readme.Content != "# Hello\n" || readme.Level != 1 {
+		t.Errorf("Unexpected README.md node %+v", readme)
+	}
+	if license := root.Children[2]; license.IsDir {
+		t.Error("LICENSE should be a file")
+	}
+}
+
+func TestParseYAML_Content(t *testing.T) {
+	input := `name: project
+children:
+  - name: main.go
+    content: |
+      package main
+  - name: docs
+    type: dir
+`
+
+	root, err := Parse(YAML, input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if mainGo := root.Children[0]; mainGo.Content != "package main\n" {
+		t.Errorf("Unexpected content %q", mainGo.Content)
+	}
+	if docs := root.Children[1]; !docs.IsDir {
+		t.Error("docs should be a directory")
+	}
+}
+
+func TestParseStructured_Errors(t *testing.T) {
+	inputs := []string{
+		`{"name": ""}`,
+		`{"name": "p", "children": [{"name": "a.txt", "type": "file", "children": [{"name": "b"}]}]}`,
+		`{"name": "p", "children": [{"name": "a", "type": "dir", "content": "x"}]}`,
+		`{"name": "p", "children": [{"name": "a", "type": "link"}]}`,
+		`not json`,
+	}
+
+	for _, input := range inputs {
+		if _, err := Parse(JSON, input); err == nil {
+			t.Errorf("Expected error for %s", input)
+		}
+	}
+	if _, err := Parse(YAML, "  "); err != parser.ErrEmptyInput {
+		t.Errorf("Expected ErrEmptyInput, got %v", err)
+	}
+}
+
+func TestParsePaths_FindOutput(t *testing.T) {
+	input := `project
+project/src
+project/src/main.go
+project/LICENSE
+project/docs/`
+
+	root, err := Parse(Paths, input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(root.Children) != 3 {
+		t.Fatalf("Expected 3 children, got %d", len(root.Children))
+	}
+	if src := root.Children[0]; !src.IsDir || len(src.Children) != 1 || src.Children[0].Level != 2 {
+		t.Errorf("Unexpected src node %+v", src)
+	}
+	if license := root.Children[1]; license.IsDir {
+		t.Error("LICENSE should be a file")
+	}
+	if docs := root.Children[2]; !docs.IsDir {
+		t.Error("docs should be a directory")
+	}
+
+	if _, err := Parse(Paths, "a/b\nc/d"); err == nil {
+		t.Error("Expected error for paths with different roots")
+	}
+}
+
+func TestParsePaths_FindDot(t *testing.T) {
+	// Verbatim output of "find ." in a small project
+	input := `.
+./src
+./src/main.go
+./go.mod
+./docs
+./docs/guide.md
+`
+
+	root, err := Parse(Paths, input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if root.Name != tree.CurrentDir || !root.IsDir {
+		t.Fatalf("Expected a %q root, got %+v", tree.CurrentDir, root)
+	}
+
+	var buf bytes.Buffer
+	if err := Render(Tree, &buf, root); err != nil {
+		t.Fatal(err)
+	}
+	expected := "./\n├── src/\n│   └── main.go\n├── go.mod\n└── docs/\n    └── guide.md\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected tree.\nExpected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+
+	// find . without the "." line
+	root, err = Parse(Paths, "./src/main.go\n./go.mod")
+	if err != nil || root.Name != tree.CurrentDir || len(root.Children) != 2 {
+		t.Errorf("Expected src and go.mod under %q, got %+v (%v)", tree.CurrentDir, root, err)
+	}
+}
+
+func TestParsePaths_LineNumbers(t *testing.T) {
+	input := "project\n\nproject/src\nproject/src/cmd/main.go\nproject/go.mod"
+
+	root, err := Parse(Paths, input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]int{"project": 1, "src": 3, "cmd": 4, "main.go": 4, "go.mod": 5}
+	root.Walk(func(node *tree.Node, _ int) error {
+		if line := expected[node.Name]; node.Line != line {
+			t.Errorf("Expected %s on line %d, got %d", node.Name, line, node.Line)
+		}
+		return nil
+	}, nil)
+}
+
+func TestParseMarkdown_Bullets(t *testing.T) {
+	input := "\n* `project/`\n    * `src/`\n\n        * `main.go` # entry\n    * go.mod\n"
+
+	root, err := Parse(Markdown, input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if root.Name != "project" || len(root.Children) != 2 {
+		t.Fatalf("Unexpected root %+v", root)
+	}
+	mainGo := root.Children[0].Children[0]
+	if mainGo.Name != "main.go" || mainGo.Comment != "entry" || mainGo.Line != 5 {
+		t.Errorf("Unexpected main.go node %+v", mainGo)
+	}
+}
+
+func TestLookupAndDetect(t *testing.T) {
+	if f, err := Lookup("YML"); err != nil || f != YAML {
+		t.Errorf("Lookup(YML) = %v, %v", f, err)
+	}
+	if _, err := Lookup("xml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+
+	detect := map[string]Format{
+		"spec.json": JSON,
+		"spec.yaml": YAML,
+		"README.md": Markdown,
+		"spec.tree": Tree,
+		"spec":      Tree,
+	}
+	for path, expected := range detect {
+		if f := Detect(path); f != expected {
+			t.Errorf("Detect(%q) = %v, expected %v", path, f, expected)
+		}
+	}
+}
+
+func TestRender_UnknownFormat(t *testing.T) {
+	if err := Render("xml", &bytes.Buffer{}, sampleTree()); err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("Expected unknown format error, got %v", err)
+	}
+}
+
+// assertSameTree compares names, types, levels and optionally comments
+func assertSameTree(t *testing.T, expected, actual *tree.Node, comments bool) {
+	t.Helper()
+	if expected.Name != actual.Name || expected.IsDir != actual.IsDir || expected.Level != actual.Level {
+		t.Fatalf("Expected node %+v, got %+v", expected, actual)
+	}
+	if comments && expected.Comment != actual.Comment {
+		t.Errorf("Expected comment %q on %s, got %q", expected.Comment, expected.Name, actual.Comment)
+	}
+	if len(expected.Children) != len(actual.Children) {
+		t.Fatalf("Expected %d children in %s, got %d", len(expected.Children), expected.Name, len(actual.Children))
+	}
+	for i := range expected.Children {
+		assertSameTree(t, expected.Children[i], actual.Children[i], comments)
+	}
+}
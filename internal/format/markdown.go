@@ -0,0 +1,100 @@
+package format
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// parseMarkdown reads a nested bullet list. The bullets are rewritten so
+// that the list becomes an indented diagram for parser.ParseInput.
+func parseMarkdown(input string) (*tree.Node, error) {
+	var lines []string
+	offset := 0
+	for _, line := range strings.Split(input, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			// Keep blank lines so that line numbers still match the input
+			if len(lines) == 0 {
+				offset++
+			} else {
+				lines = append(lines, "")
+			}
+			continue
+		}
+
+		indent := strings.ReplaceAll(line[:len(line)-len(trimmed)], "\t", "    ")
+		for _, bullet := range []string{"- ", "* ", "+ "} {
+			if strings.HasPrefix(trimmed, bullet) {
+				trimmed = strings.TrimSpace(trimmed[len(bullet):])
+				break
+			}
+		}
+		trimmed = unquoteCode(trimmed)
+
+		if len(lines) == 0 {
+			lines = append(lines, trimmed)
+			continue
+		}
+		// Keep every entry indented deeper than the root
+		lines = append(lines, indent+"  "+trimmed)
+	}
+
+	root, err := parser.ParseInput(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		shiftLines(root, offset)
+	}
+	return root, nil
+}
+
+func shiftLines(node *tree.Node, offset int) {
+	node.Line += offset
+	for _, child := range node.Children {
+		shiftLines(child, offset)
+	}
+}
+
+// unquoteCode strips backticks around a name, keeping any trailing comment
+func unquoteCode(entry string) string {
+	if !strings.HasPrefix(entry, "`") {
+		return entry
+	}
+	end := strings.Index(entry[1:], "`")
+	if end == -1 {
+		return entry
+	}
+	return entry[1:end+1] + entry[end+2:]
+}
+
+func renderMarkdown(w io.Writer, root *tree.Node) error {
+	bw := bufio.NewWriter(w)
+	writeMarkdown(bw, root, "")
+	return bw.Flush()
+}
+
+func writeMarkdown(w *bufio.Writer, node *tree.Node, indent string) {
+	w.WriteString(indent)
+	w.WriteString("- ")
+	if node.Directive != "" {
+		w.WriteString("@" + node.Directive + " ")
+	}
+	w.WriteString(node.Name)
+	if node.IsDir {
+		w.WriteString("/")
+	}
+	if node.Comment != "" {
+		w.WriteString(" # ")
+		w.WriteString(node.Comment)
+	}
+	w.WriteString("\n")
+
+	for _, child := range node.Children {
+		writeMarkdown(w, child, indent+strings.Repeat(" ", indentWidth))
+	}
+}
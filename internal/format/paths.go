@@ -0,0 +1,102 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// parsePaths reads one slash-separated path per line, such as the output
+// of find. Entries are files unless they end with a slash or other paths
+// are nested under them. When the first path is "." or starts with "./",
+// as in the output of "find .", all paths are relative to a
+// tree.CurrentDir root.
+func parsePaths(input string) (*tree.Node, error) {
+	var root *tree.Node
+	relative := false
+
+	for i, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		if line == "" {
+			continue
+		}
+		if root == nil && (line == "." || strings.HasPrefix(line, "./")) {
+			root = &tree.Node{Name: tree.CurrentDir, IsDir: true, Line: i + 1}
+			relative = true
+		}
+		line = strings.TrimPrefix(line, "./")
+		if line == "" || line == "." {
+			continue
+		}
+
+		isDir := strings.HasSuffix(line, "/")
+		segments := strings.Split(strings.Trim(line, "/"), "/")
+
+		if relative {
+			// Every segment is below the current directory
+			segments = append([]string{root.Name}, segments...)
+		} else if root == nil {
+			root = &tree.Node{Name: segments[0], IsDir: true, Line: i + 1}
+		} else if segments[0] != root.Name {
+			return nil, fmt.Errorf("path '%s' is outside the root '%s'", line, root.Name)
+		}
+
+		node := root
+		for j, segment := range segments[1:] {
+			if segment == "" {
+				continue
+			}
+			last := j == len(segments)-2
+			child := findChild(node, segment)
+			if child == nil {
+				child = &tree.Node{Name: segment, IsDir: !last || isDir, Line: i + 1}
+				node.Children = append(node.Children, child)
+			} else if !last || isDir {
+				child.IsDir = true
+			}
+			node = child
+		}
+	}
+
+	if root == nil {
+		return nil, parser.ErrEmptyInput
+	}
+
+	setLevels(root, 0)
+	return root, nil
+}
+
+func renderPaths(w io.Writer, root *tree.Node) error {
+	bw := bufio.NewWriter(w)
+	writePaths(bw, root, "")
+	return bw.Flush()
+}
+
+func writePaths(w *bufio.Writer, node *tree.Node, prefix string) {
+	path := prefix + node.Name
+	if node.IsDir {
+		path += "/"
+	}
+	w.WriteString(path)
+	w.WriteString("\n")
+
+	for _, child := range node.Children {
+		writePaths(w, child, path)
+	}
+}
+
+func findChild(parent *tree.Node, name string) *tree.Node {
+	for _, child := range parent.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
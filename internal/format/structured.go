@@ -0,0 +1,125 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/pkg/tree"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	typeDir  = "dir"
+	typeFile = "file"
+)
+
+// specNode is the JSON and YAML representation of a node
+type specNode struct {
+	Name     string      `json:"name" yaml:"name"`
+	Type     string      `json:"type,omitempty" yaml:"type,omitempty"`
+	Comment  string      `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Content  string      `json:"content,omitempty" yaml:"content,omitempty"`
+	Children []*specNode `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+func parseJSON(input string) (*tree.Node, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, parser.ErrEmptyInput
+	}
+
+	var spec specNode
+	if err := json.Unmarshal([]byte(input), &spec); err != nil {
+		return nil, err
+	}
+	return fromSpec(&spec, 0)
+}
+
+func parseYAML(input string) (*tree.Node, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, parser.ErrEmptyInput
+	}
+
+	var spec specNode
+	if err := yaml.Unmarshal([]byte(input), &spec); err != nil {
+		return nil, err
+	}
+	return fromSpec(&spec, 0)
+}
+
+func renderJSON(w io.Writer, root *tree.Node) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(toSpec(root))
+}
+
+func renderYAML(w io.Writer, root *tree.Node) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(toSpec(root)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// fromSpec converts a decoded spec node; a missing type means a directory
+// when the node has children or its name ends with a slash
+func fromSpec(spec *specNode, level int) (*tree.Node, error) {
+	name := strings.TrimSuffix(spec.Name, "/")
+	if name == "" {
+		return nil, fmt.Errorf("node at level %d has no name", level)
+	}
+
+	node := &tree.Node{
+		Name:    name,
+		Level:   level,
+		Comment: spec.Comment,
+		Content: spec.Content,
+	}
+
+	switch spec.Type {
+	case typeDir:
+		node.IsDir = true
+	case typeFile:
+		node.IsDir = false
+	case "":
+		node.IsDir = len(spec.Children) > 0 || strings.HasSuffix(spec.Name, "/") || level == 0
+	default:
+		return nil, fmt.Errorf("node '%s' has unknown type %q", name, spec.Type)
+	}
+
+	if !node.IsDir && len(spec.Children) > 0 {
+		return nil, fmt.Errorf("file '%s' cannot have children", name)
+	}
+	if node.IsDir && spec.Content != "" {
+		return nil, fmt.Errorf("directory '%s' cannot have content", name)
+	}
+
+	for _, childSpec := range spec.Children {
+		child, err := fromSpec(childSpec, level+1)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+	}
+
+	return node, nil
+}
+
+func toSpec(node *tree.Node) *specNode {
+	spec := &specNode{
+		Name:    node.Name,
+		Type:    typeFile,
+		Comment: node.Comment,
+		Content: node.Content,
+	}
+	if node.IsDir {
+		spec.Type = typeDir
+	}
+	for _, child := range node.Children {
+		spec.Children = append(spec.Children, toSpec(child))
+	}
+	return spec
+}
@@ -0,0 +1,182 @@
+package gitrepo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// DefaultMessage is the message of the initial commit
+const DefaultMessage = "Initial commit"
+
+// ErrGitNotFound is returned when no git executable is on the PATH
+var ErrGitNotFound = errors.New("git executable not found in PATH")
+
+// language describes what a .gitignore should exclude for one language
+type language struct {
+	name     string
+	patterns []string
+}
+
+var (
+	golang = language{"Go", []string{"*.exe", "*.test", "*.out", "/bin/"}}
+	python = language{"Python", []string{"__pycache__/", "*.py[cod]", ".venv/", "*.egg-info/", "dist/", "build/"}}
+	nodejs = language{"Node", []string{"node_modules/", "npm-debug.log*", "dist/"}}
+	rust   = language{"Rust", []string{"target/"}}
+	java   = language{"Java", []string{"*.class", "*.jar", "target/", "build/", ".gradle/"}}
+	ruby   = language{"Ruby", []string{".bundle/", "vendor/bundle/"}}
+	clang  = language{"C", []string{"*.o", "*.a", "*.so"}}
+)
+
+// extensions maps file extensions to the language they belong to
+var extensions = map[string]language{
+	".go":   golang,
+	".py":   python,
+	".js":   nodejs,
+	".jsx":  nodejs,
+	".mjs":  nodejs,
+	".cjs":  nodejs,
+	".ts":   nodejs,
+	".tsx":  nodejs,
+	".rs":   rust,
+	".java": java,
+	".kt":   java,
+	".rb":   ruby,
+	".c":    clang,
+	".h":    clang,
+	".cpp":  clang,
+}
+
+// Options control the repository set up after a build
+type Options struct {
+	Commit  bool   // Stage and commit Paths
+	Message string // Commit message (DefaultMessage if empty)
+	// Paths are the files to commit, relative to dir. Other files in dir,
+	// such as those that were there before the build, are not staged.
+	Paths []string
+}
+
+// Init creates a git repository in dir and optionally commits the files
+// in opts.Paths. Nothing is committed when there are none.
+func Init(dir string, opts Options) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return ErrGitNotFound
+	}
+
+	if err := git(dir, "init", "--quiet"); err != nil {
+		return err
+	}
+	if !opts.Commit || len(opts.Paths) == 0 {
+		return nil
+	}
+
+	message := opts.Message
+	if message == "" {
+		message = DefaultMessage
+	}
+	// Paths go through stdin, so that large trees do not hit the
+	// argument limit
+	if err := gitInput(dir, strings.Join(opts.Paths, "\x00"), "add", "--pathspec-from-file=-", "--pathspec-file-nul"); err != nil {
+		return err
+	}
+	return git(dir, "commit", "--quiet", "--message", message)
+}
+
+// GitIgnore returns .gitignore content for the languages detected in the
+// tree, or "" when none are detected
+func GitIgnore(root *tree.Node) string {
+	var b strings.Builder
+	seen := map[string]bool{}
+	for _, lang := range detect(root) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# %s\n", lang.name)
+		for _, pattern := range lang.patterns {
+			if seen[pattern] {
+				continue
+			}
+			seen[pattern] = true
+			b.WriteString(pattern + "\n")
+		}
+	}
+	return b.String()
+}
+
+// WriteGitIgnore writes a generated .gitignore into dir unless one exists.
+// It reports whether a file was written.
+func WriteGitIgnore(dir string, root *tree.Node) (bool, error) {
+	content := GitIgnore(root)
+	if content == "" {
+		return false, nil
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, ".gitignore"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return false, err
+	}
+	return true, f.Close()
+}
+
+func detect(root *tree.Node) []language {
+	found := map[string]language{}
+	var walk func(node *tree.Node)
+	walk = func(node *tree.Node) {
+		if !node.IsDir && node.Directive == "" {
+			if lang, ok := extensions[strings.ToLower(filepath.Ext(node.Name))]; ok {
+				found[lang.name] = lang
+			}
+			if node.Name == "package.json" {
+				found[nodejs.name] = nodejs
+			}
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	langs := make([]language, 0, len(found))
+	for _, lang := range found {
+		langs = append(langs, lang)
+	}
+	sort.Slice(langs, func(i, j int) bool { return langs[i].name < langs[j].name })
+	return langs
+}
+
+// git runs a git command in dir, including its output in errors
+func git(dir string, args ...string) error {
+	return gitInput(dir, "", args...)
+}
+
+// gitInput is like git, with input on stdin. Pathspecs are taken
+// literally, so that names such as "[id].tsx" are not patterns.
+func gitInput(dir, input string, args ...string) error {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_LITERAL_PATHSPECS=1")
+	cmd.Stdin = strings.NewReader(input)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(output.String()); msg != "" {
+			return fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package gitrepo
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+func TestGitIgnore(t *testing.T) {
+	root, err := parser.ParseInput(`app/
+├── main.go
+├── web/
+│   ├── package.json
+│   └── index.TS
+├── tools/
+│   └── gen.go
+└── README.md`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := GitIgnore(root)
+	if !strings.HasPrefix(content, "# Go\n*.exe\n") || !strings.Contains(content, "\n# Node\nnode_modules/\n") {
+		t.Errorf("Unexpected .gitignore:\n%s", content)
+	}
+	if strings.Count(content, "dist/") != 1 {
+		t.Errorf("Patterns should not repeat:\n%s", content)
+	}
+
+	if plain := GitIgnore(&tree.Node{Name: "docs", IsDir: true}); plain != "" {
+		t.Errorf("Expected no .gitignore without languages, got %q", plain)
+	}
+}
+
+func TestWriteGitIgnore(t *testing.T) {
+	dir := t.TempDir()
+	root := &tree.Node{Name: "app", IsDir: true, Children: []*tree.Node{{Name: "app.py", Level: 1}}}
+
+	written, err := WriteGitIgnore(dir, root)
+	if err != nil || !written {
+		t.Fatalf("Expected .gitignore to be written, got %v, %v", written, err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil || !strings.Contains(string(content), "__pycache__/") {
+		t.Errorf("Unexpected .gitignore %q (%v)", content, err)
+	}
+
+	// An existing file is kept
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("custom\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if written, err := WriteGitIgnore(dir, root); err != nil || written {
+		t.Errorf("Expected existing .gitignore to be kept, got %v, %v", written, err)
+	}
+}
+
+func TestInit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	setGitIdentity(t)
+
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "[id].go", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// notes.txt is not one of the paths, like a file that predates the build
+	if err := Init(dir, Options{Commit: true, Message: "Scaffold project", Paths: []string{"main.go", "[id].go"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	out, err := exec.Command("git", "-C", dir, "log", "--format=%s", "--name-only").Output()
+	if err != nil {
+		t.Fatalf("git log: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "Scaffold project\n\n[id].go\nmain.go" {
+		t.Errorf("Unexpected history %q", got)
+	}
+
+	out, err = exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	if err != nil {
+		t.Fatalf("git status: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "?? notes.txt" {
+		t.Errorf("Expected notes.txt to stay untracked, got %q", got)
+	}
+}
+
+func TestInit_NoGit(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	if err := Init(t.TempDir(), Options{}); !errors.Is(err, ErrGitNotFound) {
+		t.Errorf("Expected ErrGitNotFound, got %v", err)
+	}
+}
+
+func setGitIdentity(t *testing.T) {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+}
@@ -0,0 +1,50 @@
+package glob
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether a slash-separated name matches the pattern.
+// Each segment is matched with path.Match, and a "**" segment matches
+// zero or more whole segments.
+func Match(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// HasMeta reports whether the string contains any glob metacharacters
+func HasMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse repeated "**" segments
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern, segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+		ok, err := path.Match(pattern[0], segments[0])
+		if err != nil || !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		segments = segments[1:]
+	}
+
+	return len(segments) == 0
+}
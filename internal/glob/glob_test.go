@@ -0,0 +1,45 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "src/main.go", false},
+		{"src/*.go", "src/main.go", true},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/c/main.go", true},
+		{"**/*_test.go", "pkg/util_test.go", true},
+		{"**/*_test.go", "pkg/util.go", false},
+		{"src/**", "src", true},
+		{"src/**", "src/a/b.txt", true},
+		{"src/**", "docs/a.txt", false},
+		{"a/**/z", "a/z", true},
+		{"a/**/z", "a/b/c/z", true},
+		{"a/**/z", "a/b/c/y", false},
+		{"file?.txt", "file1.txt", true},
+		{"[abc].txt", "d.txt", false},
+		{"[", "[", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"|"+tt.name, func(t *testing.T) {
+			if result := Match(tt.pattern, tt.name); result != tt.expected {
+				t.Errorf("Match(%q, %q) = %v, expected %v", tt.pattern, tt.name, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHasMeta(t *testing.T) {
+	if !HasMeta("*.go") || !HasMeta("file?.txt") || !HasMeta("[ab]") {
+		t.Error("Expected patterns to contain metacharacters")
+	}
+	if HasMeta("main.go") {
+		t.Error("Plain name should not contain metacharacters")
+	}
+}
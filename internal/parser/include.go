@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"github.com/neomen/buildtree/pkg/tree"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrIncludeCycle is returned when a spec includes itself, directly or not
+var ErrIncludeCycle = errors.New("include cycle")
+
+// IncludeError reports a failed @include directive and where it appears
+type IncludeError struct {
+	File string // Spec containing the directive ("" for inline input)
+	Line int
+	Path string // Argument of the directive
+	Err  error
+}
+
+func (e *IncludeError) Error() string {
+	file := e.File
+	if file == "" {
+		file = "<input>"
+	}
+	return fmt.Sprintf("%s:%d: @include %s: %v", file, e.Line, e.Path, e.Err)
+}
+
+func (e *IncludeError) Unwrap() error {
+	return e.Err
+}
+
+// ResolveIncludes replaces every @include directive in the tree with the
+// children of the included spec, at the directive's level. Relative paths
+// are resolved from the directory of file, the spec the tree was read from
+// ("" for the current directory). Nodes are tagged with their source file.
+func ResolveIncludes(root *tree.Node, file string) error {
+	var stack []string
+	if file != "" {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		stack = append(stack, abs)
+	}
+	if err := resolveIncludes(root, file, stack); err != nil {
+		return err
+	}
+	root.SetParents()
+	return nil
+}
+
+func resolveIncludes(node *tree.Node, file string, stack []string) error {
+	if node.File == "" {
+		node.File = file
+	}
+
+	children := make([]*tree.Node, 0, len(node.Children))
+	for _, child := range node.Children {
+		if child.Directive != DirectiveInclude {
+			if err := resolveIncludes(child, file, stack); err != nil {
+				return err
+			}
+			children = append(children, child)
+			continue
+		}
+
+		included, err := include(child, file, stack)
+		if err != nil {
+			return &IncludeError{File: file, Line: child.Line, Path: child.Name, Err: err}
+		}
+		children = append(children, included...)
+	}
+
+	node.Children = children
+	return nil
+}
+
+// include parses the spec named by the directive and returns its children
+func include(directive *tree.Node, file string, stack []string) ([]*tree.Node, error) {
+	target := directive.Name
+	if target == "" {
+		return nil, errors.New("missing path")
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(file), target)
+	}
+
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return nil, err
+	}
+	for i, seen := range stack {
+		if seen == abs {
+			chain := append(append([]string{}, stack[i:]...), abs)
+			for j := range chain {
+				chain[j] = filepath.Base(chain[j])
+			}
+			return nil, fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(chain, " -> "))
+		}
+	}
+
+	content, err := os.ReadFile(target)
+	if err != nil {
+		return nil, err
+	}
+	root, err := ParseInput(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", target, err)
+	}
+
+	if err := resolveIncludes(root, target, append(stack, abs)); err != nil {
+		return nil, err
+	}
+
+	// The included root's children take the place of the directive
+	for _, child := range root.Children {
+		shiftLevel(child, directive.Level-1)
+	}
+	return root.Children, nil
+}
+
+func shiftLevel(node *tree.Node, delta int) {
+	node.Walk(func(n *tree.Node, _ int) error {
+		n.Level += delta
+		return nil
+	}, nil)
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"errors"
+	"github.com/neomen/buildtree/pkg/tree"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeSpec(t, dir, "main.tree", `project/
+├── @include parts/backend.tree
+└── README.md`)
+	writeSpec(t, dir, "parts/backend.tree", `backend/
+├── api/
+│   └── @include ../shared.tree
+└── go.mod`)
+	writeSpec(t, dir, "shared.tree", `shared/
+└── errors.go`)
+
+	root := readSpec(t, filepath.Join(dir, "main.tree"))
+	if err := ResolveIncludes(root, filepath.Join(dir, "main.tree")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(root.Children) != 3 {
+		t.Fatalf("Expected 3 children, got %d", len(root.Children))
+	}
+	api := root.Children[0]
+	if api.Name != "api" || api.Level != 1 {
+		t.Fatalf("Expected api at level 1, got %+v", api)
+	}
+	if len(api.Children) != 1 || api.Children[0].Name != "errors.go" || api.Children[0].Level != 2 {
+		t.Errorf("Expected errors.go spliced under api, got %+v", api.Children)
+	}
+	if got := api.Children[0].File; got != filepath.Join(dir, "shared.tree") {
+		t.Errorf("Expected errors.go from shared.tree, got %q", got)
+	}
+	if goMod := root.Children[1]; goMod.Name != "go.mod" || goMod.Line != 4 {
+		t.Errorf("Expected go.mod from line 4 of the included spec, got %+v", goMod)
+	}
+	if readme := root.Children[2]; readme.Name != "README.md" || readme.File != filepath.Join(dir, "main.tree") {
+		t.Errorf("Unexpected README.md node %+v", readme)
+	}
+}
+
+func TestResolveIncludes_Cycle(t *testing.T) {
+	dir := t.TempDir()
+	writeSpec(t, dir, "a.tree", `a/
+└── @include b.tree`)
+	writeSpec(t, dir, "b.tree", `b/
+└── @include a.tree`)
+
+	err := ResolveIncludes(readSpec(t, filepath.Join(dir, "a.tree")), filepath.Join(dir, "a.tree"))
+	if !errors.Is(err, ErrIncludeCycle) {
+		t.Fatalf("Expected include cycle, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "a.tree -> b.tree -> a.tree") {
+		t.Errorf("Expected the cycle in the message, got %q", err)
+	}
+}
+
+func TestResolveIncludes_MissingFile(t *testing.T) {
+	dir := t.TempDir()
+	spec := writeSpec(t, dir, "main.tree", `project/
+├── src/
+└── @include missing.tree`)
+
+	err := ResolveIncludes(readSpec(t, spec), spec)
+	var includeErr *IncludeError
+	if !errors.As(err, &includeErr) {
+		t.Fatalf("Expected IncludeError, got %v", err)
+	}
+	if includeErr.File != spec || includeErr.Line != 3 || includeErr.Path != "missing.tree" {
+		t.Errorf("Unexpected error location %+v", includeErr)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the underlying not-exist error, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), spec+":3: @include missing.tree") {
+		t.Errorf("Unexpected message %q", err)
+	}
+}
+
+func TestResolveIncludes_InlineInput(t *testing.T) {
+	root, err := ParseInput(`project/
+└── @include nowhere.tree`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	err = ResolveIncludes(root, "")
+	if err == nil || !strings.HasPrefix(err.Error(), "<input>:2:") {
+		t.Errorf("Expected error citing <input>:2, got %v", err)
+	}
+}
+
+func writeSpec(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readSpec parses a spec file without resolving its includes
+func readSpec(t *testing.T, path string) *tree.Node {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := ParseInput(string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
@@ -7,96 +7,113 @@ import (
 	"unicode/utf8"
 
 	"github.com/neomen/buildtree/internal/utils"
+	"github.com/neomen/buildtree/pkg/tree"
 )
 
 var ErrEmptyInput = errors.New("input is empty")
 
-// Node represents a file or directory in the tree
-type Node struct {
-	Name     string
-	IsDir    bool
-	Level    int
-	Children []*Node
-}
-
-// ParseInput converts text input to a tree structure
-func ParseInput(input string) (*Node, error) {
-	input = normalizeTreeSymbols(input)
-	lines := strings.Split(input, "\n")
+// Directive keywords recognized after "@" at the start of a name
+const (
+	// DirectiveInclude splices the children of another spec file in place of the line
+	DirectiveInclude = "include"
+	// DirectiveIf keeps the lines nested under it only when its expression holds
+	DirectiveIf = "if"
+	// DirectiveEach repeats the lines nested under it for every item of a list
+	DirectiveEach = "each"
+)
 
-	// Check that the input is not empty after processing
-	if strings.TrimSpace(input) == "" {
-		return nil, ErrEmptyInput
-	}
+var directives = []string{DirectiveInclude, DirectiveIf, DirectiveEach}
 
-	if len(lines) == 0 {
-		return nil, ErrEmptyInput
-	}
+// ParseInput converts text input to a tree structure. Several top-level
+// entries are gathered under a CurrentDir root.
+func ParseInput(input string) (*tree.Node, error) {
+	s := NewScanner(strings.NewReader(input))
 
-	// Parse root directory
-	rootLine := strings.TrimSpace(lines[0])
-	if idx := strings.Index(rootLine, "#"); idx != -1 {
-		rootLine = strings.TrimSpace(rootLine[:idx])
+	var roots []*tree.Node
+	for s.Scan() {
+		node := s.Node()
+		if parent := s.Parent(); parent != nil {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
 	}
-	rootLine = strings.TrimSuffix(rootLine, "/")
-
-	root := &Node{
-		Name:  rootLine,
-		IsDir: true,
-		Level: 0,
+	if err := s.Err(); err != nil {
+		return nil, err
 	}
 
-	stack := []*Node{root}
-	prevLevel := 0
-
-	for _, line := range lines[1:] {
-		line = strings.TrimRight(line, " ")
-		if strings.TrimSpace(line) == "" {
-			continue
+	root := roots[0]
+	if len(roots) > 1 {
+		root = &tree.Node{Name: tree.CurrentDir, IsDir: true, Children: roots}
+		for _, top := range roots {
+			shiftLevel(top, 1)
 		}
+	}
+	root.SetParents()
+	return root, nil
+}
 
-		// Remove comments
-		if idx := strings.Index(line, "#"); idx != -1 {
-			line = line[:idx]
+// indentWidth returns the number of leading tree symbols, including
+// glyphs written right before the name without a space
+func indentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		if !utils.IsTreeSymbol(r) {
+			break
 		}
+		width++
+	}
+	return width
+}
 
-		level, name, isDir := parseLine(line)
-		if name == "" {
-			continue
+// parseDirective recognizes "@keyword argument" lines for known keywords
+func parseDirective(name string) (keyword, arg string, ok bool) {
+	if !strings.HasPrefix(name, "@") {
+		return "", "", false
+	}
+	keyword, arg, _ = strings.Cut(name[1:], " ")
+	for _, known := range directives {
+		if keyword == known {
+			return keyword, strings.TrimSpace(arg), true
 		}
+	}
+	return "", "", false
+}
 
-		// Adjust stack based on level
-		if level <= prevLevel {
-			stack = stack[:level+1]
-		} else if level > len(stack)-1 {
-			// Handle skipped levels
-			for len(stack) <= level {
-				stack = append(stack, stack[len(stack)-1])
-			}
+// stripActions removes {{ ... }} template actions from a name
+func stripActions(name string) string {
+	for {
+		start := strings.Index(name, "{{")
+		if start == -1 {
+			return name
 		}
-
-		parent := stack[level]
-		node := &Node{
-			Name:  name,
-			IsDir: isDir,
-			Level: level,
+		end := strings.Index(name[start:], "}}")
+		if end == -1 {
+			return name
 		}
+		name = name[:start] + name[start+end+2:]
+	}
+}
 
-		parent.Children = append(parent.Children, node)
-
-		if isDir {
-			// Add to stack for children
-			if level+1 < len(stack) {
-				stack[level+1] = node
-			} else {
-				stack = append(stack, node)
-			}
-		}
+// extractComment returns the trimmed text after the first "#" on the line
+func extractComment(line string) string {
+	if idx := strings.Index(line, "#"); idx != -1 {
+		return strings.TrimSpace(line[idx+1:])
+	}
+	return ""
+}
 
-		prevLevel = level
+// stripComment removes a trailing "#" comment from the line
+func stripComment(line string) string {
+	if idx := strings.Index(line, "#"); idx != -1 {
+		return line[:idx]
 	}
+	return line
+}
 
-	return root, nil
+// hasDirSuffix reports whether the name on the line ends with a slash
+func hasDirSuffix(line string) bool {
+	return strings.HasSuffix(strings.TrimSpace(stripComment(line)), "/")
 }
 
 func parseLine(line string) (level int, name string, isDir bool) {
@@ -132,7 +149,9 @@ func parseLine(line string) (level int, name string, isDir bool) {
 		// Checking if the name has an extension
 		// If there is no dot in the name, it is possible that it is a directory without a slash
 		// This is a heuristic, it can be improved
-		if !strings.Contains(name, ".") && !strings.Contains(name, string(os.PathSeparator)) {
+		// Template placeholders such as {{ .Name }} do not count as extensions
+		plain := stripActions(name)
+		if !strings.Contains(plain, ".") && !strings.Contains(plain, string(os.PathSeparator)) {
 			isDir = true
 		}
 	}
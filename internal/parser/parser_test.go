@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"github.com/neomen/buildtree/pkg/tree"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -328,7 +330,7 @@ func TestNormalizeTreeSymbols(t *testing.T) {
 }
 
 // Helper function to find a child node by name
-func findChild(parent *Node, name string) *Node {
+func findChild(parent *tree.Node, name string) *tree.Node {
 	for _, child := range parent.Children {
 		if child.Name == name {
 			return child
@@ -336,3 +338,316 @@ func findChild(parent *Node, name string) *Node {
 	}
 	return nil
 }
+
+func TestParseInput_ExplicitDirectories(t *testing.T) {
+	input := `project/
+├── cmd
+│   └── main.go
+├── docs/
+├── LICENSE
+└── Makefile`
+
+	root, err := ParseInput(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := map[string]bool{
+		"cmd":      true,
+		"docs":     true,
+		"LICENSE":  false,
+		"Makefile": false,
+	}
+	for name, isDir := range tests {
+		node := findChild(root, name)
+		if node == nil {
+			t.Fatalf("%s not found", name)
+		}
+		if node.IsDir != isDir {
+			t.Errorf("Expected %s isDir=%t, got %t", name, isDir, node.IsDir)
+		}
+	}
+}
+
+func TestParseInput_ImplicitDirectories(t *testing.T) {
+	input := `project
+├── docs
+└── main.go`
+
+	root, err := ParseInput(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Without any trailing slashes, names without an extension are directories
+	if docs := findChild(root, "docs"); docs == nil || !docs.IsDir {
+		t.Error("docs should be a directory")
+	}
+}
+
+func TestParseInput_DirectoriesBeforeFirstSlash(t *testing.T) {
+	input := `project
+├── LICENSE
+├── src/
+│   └── Makefile
+└── NOTICE`
+
+	root, err := ParseInput(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The trailing slash only affects the lines after it
+	tests := map[string]bool{"LICENSE": true, "src": true, "NOTICE": false}
+	for name, isDir := range tests {
+		if node := findChild(root, name); node == nil || node.IsDir != isDir {
+			t.Errorf("Expected %s isDir=%t, got %+v", name, isDir, node)
+		}
+	}
+	if makefile := findChild(root.Children[1], "Makefile"); makefile == nil || makefile.IsDir {
+		t.Errorf("Makefile should be a file, got %+v", makefile)
+	}
+}
+
+func TestParseInput_CommentsPreserved(t *testing.T) {
+	input := `project/ # The project
+├── src/ # Source code
+│   └── main.go
+└── README.md #Docs`
+
+	root, err := ParseInput(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if root.Comment != "The project" {
+		t.Errorf("Expected root comment 'The project', got '%s'", root.Comment)
+	}
+	if src := findChild(root, "src"); src == nil || src.Comment != "Source code" {
+		t.Errorf("Expected src comment 'Source code', got %+v", src)
+	}
+	if readme := findChild(root, "README.md"); readme == nil || readme.Comment != "Docs" {
+		t.Errorf("Expected README.md comment 'Docs', got %+v", readme)
+	}
+	if mainGo := root.Children[0].Children[0]; mainGo.Comment != "" {
+		t.Errorf("Expected no comment on main.go, got '%s'", mainGo.Comment)
+	}
+}
+
+func TestParseInput_IndentWidths(t *testing.T) {
+	inputs := map[string]string{
+		"two columns": `project/
+├ src/
+│ └ main.go
+└ go.mod`,
+		"indented list": `project/
+  src/
+    main.go
+  go.mod`,
+		"six columns": `project/
+├──── src/
+│     └──── main.go
+└──── go.mod`,
+	}
+
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			root, err := ParseInput(input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(root.Children) != 2 {
+				t.Fatalf("Expected 2 children, got %d", len(root.Children))
+			}
+			src := root.Children[0]
+			if src.Name != "src" || src.Level != 1 || len(src.Children) != 1 {
+				t.Fatalf("Unexpected src node %+v", src)
+			}
+			if mainGo := src.Children[0]; mainGo.Name != "main.go" || mainGo.Level != 2 {
+				t.Errorf("Unexpected main.go node %+v", mainGo)
+			}
+		})
+	}
+}
+
+func TestParseInput_CompactGlyphs(t *testing.T) {
+	input := "app/\n├──src/\n│  ├──main.go\n│  └──lib/\n│     └──a.go"
+
+	root, err := ParseInput(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var paths []string
+	root.Walk(func(node *tree.Node, _ int) error {
+		paths = append(paths, node.Path())
+		return nil
+	}, nil)
+	expected := []string{"app", "app/src", "app/src/main.go", "app/src/lib", "app/src/lib/a.go"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+}
+
+func TestParseInput_LineNumbers(t *testing.T) {
+	input := `project/
+
+├── src/
+│   └── main.go # entry point
+└── README.md`
+
+	root, err := ParseInput(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if root.Line != 1 {
+		t.Errorf("Expected root on line 1, got %d", root.Line)
+	}
+	src := root.Children[0]
+	if src.Line != 3 {
+		t.Errorf("Expected src on line 3, got %d", src.Line)
+	}
+	if src.Children[0].Line != 4 {
+		t.Errorf("Expected main.go on line 4, got %d", src.Children[0].Line)
+	}
+	if root.Children[1].Line != 5 {
+		t.Errorf("Expected README.md on line 5, got %d", root.Children[1].Line)
+	}
+}
+
+func TestParseInput_TemplatePlaceholders(t *testing.T) {
+	input := `{{ .Name }}
+├── cmd
+│   └── {{ .Name }}
+│       └── main.go
+└── {{ .Name }}.yaml`
+
+	root, err := ParseInput(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cmd := findChild(root, "cmd")
+	if cmd == nil || len(cmd.Children) != 1 {
+		t.Fatalf("Unexpected cmd node %+v", cmd)
+	}
+	if named := cmd.Children[0]; !named.IsDir || len(named.Children) != 1 {
+		t.Errorf("Placeholder without an extension should be a directory, got %+v", named)
+	}
+	if config := findChild(root, "{{ .Name }}.yaml"); config == nil || config.IsDir {
+		t.Errorf("Placeholder with an extension should be a file, got %+v", config)
+	}
+}
+
+func TestParseInput_Directives(t *testing.T) {
+	input := `project/
+├── @include shared/lint.tree
+├── src/
+│   └── @include ../common.tree
+└── @unknown.txt`
+
+	root, err := ParseInput(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	include := root.Children[0]
+	if include.Directive != DirectiveInclude || include.Name != "shared/lint.tree" || include.IsDir {
+		t.Errorf("Unexpected include node %+v", include)
+	}
+	src := root.Children[1]
+	if len(src.Children) != 1 || src.Children[0].Directive != DirectiveInclude || src.Children[0].Level != 2 {
+		t.Errorf("Unexpected nested include %+v", src.Children)
+	}
+	if other := root.Children[2]; other.Directive != "" || other.Name != "@unknown.txt" {
+		t.Errorf("Unknown keywords should stay plain names, got %+v", other)
+	}
+}
+
+func TestParseInput_Blocks(t *testing.T) {
+	input := `project/
+├── @if eq .db "postgres"
+│   └── migrations/
+└── @each svc in services
+    └── {{ .svc }}.yaml`
+
+	root, err := ParseInput(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cond := root.Children[0]
+	if cond.Directive != DirectiveIf || cond.Name != `eq .db "postgres"` {
+		t.Errorf("Unexpected @if node %+v", cond)
+	}
+	if len(cond.Children) != 1 || cond.Children[0].Name != "migrations" || !cond.Children[0].IsDir {
+		t.Errorf("Expected migrations/ nested under @if, got %+v", cond.Children)
+	}
+	loop := root.Children[1]
+	if loop.Directive != DirectiveEach || loop.Name != "svc in services" || len(loop.Children) != 1 {
+		t.Errorf("Unexpected @each node %+v", loop)
+	}
+}
+
+func TestParseInput_Rootless(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"dot root", ".\n├── src/\n│   └── main.go\n└── go.mod", "src/\nsrc/main.go\ngo.mod"},
+		{"dot slash root", "./ # here\n└── docs/", "docs/"},
+		{"indented first line", "├── src/\n│   └── main.go\n└── go.mod", "src/\nsrc/main.go\ngo.mod"},
+		{"file first line", "main.go\n\ngo.mod\nsrc/\n  util.go", "main.go\ngo.mod\nsrc/\nsrc/util.go"},
+		{"single file", "README.md", "README.md"},
+		{"several roots", "app/\n└── main.go\nlib/\n└── lib.go\nREADME.md", "app/\napp/main.go\nlib/\nlib/lib.go\nREADME.md"},
+		{"directive first line", "@include base.tree\nextra.txt", "@include base.tree\nextra.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ParseInput(tt.input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if root.Name != tree.CurrentDir || !root.IsDir {
+				t.Fatalf("Expected a %q root, got %+v", tree.CurrentDir, root)
+			}
+			var lines []string
+			root.Walk(func(node *tree.Node, depth int) error {
+				if depth == 0 {
+					return nil
+				}
+				if node.Level != depth {
+					t.Errorf("Expected %s at level %d, got %d", node.Name, depth, node.Level)
+				}
+				if node.Parent() == nil {
+					t.Errorf("Expected %s to have a parent", node.Name)
+				}
+				line := strings.TrimPrefix(node.Path(), tree.CurrentDir+"/")
+				if node.Directive != "" {
+					line = "@" + node.Directive + " " + node.Name
+				}
+				if node.IsDir {
+					line += "/"
+				}
+				lines = append(lines, line)
+				return nil
+			}, nil)
+			if got := strings.Join(lines, "\n"); got != tt.expected {
+				t.Errorf("Expected\n%s\ngot\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseInput_FileNamedRoot(t *testing.T) {
+	root, err := ParseInput("site.io\n├── index.html\n└── css/")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if root.Name != "site.io" || len(root.Children) != 2 {
+		t.Errorf("Expected root site.io with 2 children, got %+v", root)
+	}
+}
@@ -0,0 +1,320 @@
+package parser
+
+import (
+	"bufio"
+	"errors"
+	"github.com/neomen/buildtree/pkg/tree"
+	"io"
+	"strings"
+)
+
+// Scanner reads a tree diagram line by line and returns its nodes in
+// order, root first, without building the tree. Memory use is bounded by
+// the depth of the tree rather than the size of the input.
+//
+// Nodes are returned one line late, once it is known whether anything is
+// nested under them. In a diagram that marks directories with a trailing
+// slash, an unmarked entry without children is a file; a Scanner only
+// knows about slashes on the lines read so far, while ParseInput looks at
+// the whole input.
+//
+// A diagram whose first line is "." or "./", a file or an indented entry
+// has a root named CurrentDir. Unindented lines after any other root start
+// further top-level nodes, which have no Parent.
+type Scanner struct {
+	r      *bufio.Reader
+	line   int
+	unread []pendingLine // Lines to return again from readLine, last first
+	eof    bool
+	err    error
+
+	root      *tree.Node
+	stack     []*tree.Node
+	widths    []int // Indentation widths of the open levels
+	prevLevel int
+	explicit  bool // A directory was marked with a trailing slash so far
+	current   bool // The root is CurrentDir, so unindented lines are its children
+	offset    int  // Levels added to indented lines under an implicit root
+
+	pending        *tree.Node // Parsed, waiting for the next line
+	pendingParent  *tree.Node
+	pendingGuessed bool // Dot-less leaf after a trailing slash, a file unless it has children
+
+	node   *tree.Node
+	parent *tree.Node
+}
+
+// NewScanner returns a Scanner reading from r
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{r: bufio.NewReader(r)}
+}
+
+// Scan advances to the next node, which is then available through Node
+// and Parent. It returns false at the end of the input or on an error.
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	if s.root == nil {
+		return s.scanRoot()
+	}
+
+	for {
+		node, parent, guessed, ok := s.next()
+		if s.err != nil {
+			return false
+		}
+		if !ok {
+			// End of input: the last node has no children
+			return s.emit(false)
+		}
+
+		hasChildren := s.pending != nil && parent == s.pending
+		emitted := s.emit(hasChildren)
+		s.pending, s.pendingParent, s.pendingGuessed = node, parent, guessed
+		if emitted {
+			return true
+		}
+	}
+}
+
+// Node returns the node read by the last call to Scan. Its Children are
+// not filled in.
+func (s *Scanner) Node() *tree.Node {
+	return s.node
+}
+
+// Parent returns the parent of the current node, or nil for a top-level
+// node
+func (s *Scanner) Parent() *tree.Node {
+	return s.parent
+}
+
+// Err returns the first error other than io.EOF met by the Scanner
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// emit makes the pending node current, turning a guessed directory into a
+// file when nothing was nested under it
+func (s *Scanner) emit(hasChildren bool) bool {
+	if s.pending == nil {
+		return false
+	}
+	if s.pendingGuessed && !hasChildren {
+		s.pending.IsDir = false
+	}
+	s.node, s.parent = s.pending, s.pendingParent
+	s.pending, s.pendingParent = nil, nil
+	return true
+}
+
+// scanRoot reads the first non-blank line as the root directory, or
+// makes up a CurrentDir root when the line is an entry of a root-less
+// diagram
+func (s *Scanner) scanRoot() bool {
+	if !s.skipBlank() {
+		if s.err == nil {
+			s.err = ErrEmptyInput
+		}
+		return false
+	}
+	raw, _ := s.readLine()
+	number := s.line
+	first := normalizeTreeSymbols(raw)
+
+	rootLine := strings.TrimSpace(first)
+	if idx := strings.Index(rootLine, "#"); idx != -1 {
+		rootLine = strings.TrimSpace(rootLine[:idx])
+	}
+	rootLine = strings.TrimSuffix(rootLine, "/")
+
+	if rootLine != tree.CurrentDir && s.rootless(first) {
+		// The first line is read again as a child of the made-up root
+		s.root = &tree.Node{Name: tree.CurrentDir, IsDir: true}
+		s.current = true
+		if indentWidth(first) == 0 {
+			s.offset = 1
+		}
+		s.unread = append(s.unread, pendingLine{raw, number})
+	} else {
+		s.root = &tree.Node{
+			Name:    rootLine,
+			IsDir:   true,
+			Level:   0,
+			Line:    number,
+			Comment: extractComment(first),
+		}
+		s.current = rootLine == tree.CurrentDir
+		s.explicit = hasDirSuffix(first)
+	}
+	s.stack = []*tree.Node{s.root}
+
+	s.node, s.parent = s.root, nil
+	return true
+}
+
+// rootless reports whether the first line of a diagram is an entry rather
+// than its root: an indented line, a directive, or a file with nothing
+// nested under it
+func (s *Scanner) rootless(first string) bool {
+	if indentWidth(first) > 0 {
+		return true
+	}
+	_, name, isDir := parseLine(first)
+	if _, _, ok := parseDirective(name); ok {
+		return true
+	}
+	if isDir || hasDirSuffix(first) {
+		return false
+	}
+	if !s.skipBlank() {
+		return true
+	}
+	next := s.unread[len(s.unread)-1].text
+	return indentWidth(normalizeTreeSymbols(next)) == 0
+}
+
+// skipBlank reads up to the next non-blank line and leaves it to be read
+// again. It reports whether there is one.
+func (s *Scanner) skipBlank() bool {
+	for {
+		line, ok := s.readLine()
+		if !ok {
+			return false
+		}
+		if strings.TrimSpace(line) != "" {
+			s.unread = append(s.unread, pendingLine{line, s.line})
+			return true
+		}
+	}
+}
+
+// next parses lines until one holds a node and returns it with its parent
+func (s *Scanner) next() (node, parent *tree.Node, guessed, ok bool) {
+	for {
+		line, more := s.readLine()
+		if !more {
+			return nil, nil, false, false
+		}
+		line = normalizeTreeSymbols(line)
+
+		line = strings.TrimRight(line, " ")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// Remove comments
+		comment := extractComment(line)
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = line[:idx]
+		}
+
+		_, name, isDir := parseLine(line)
+		if name == "" {
+			continue
+		}
+
+		level := 0
+		if width := indentWidth(line); width > 0 {
+			for len(s.widths) > 0 && width < s.widths[len(s.widths)-1] {
+				s.widths = s.widths[:len(s.widths)-1]
+			}
+			if len(s.widths) == 0 || width > s.widths[len(s.widths)-1] {
+				s.widths = append(s.widths, width)
+			}
+			level = len(s.widths) + s.offset
+		} else {
+			s.widths = nil
+			if s.current {
+				level = 1
+			}
+		}
+
+		// Adjust stack based on level
+		if level <= s.prevLevel {
+			s.stack = s.stack[:level+1]
+		} else if level > len(s.stack)-1 {
+			// Handle skipped levels
+			for len(s.stack) <= level {
+				s.stack = append(s.stack, s.stack[len(s.stack)-1])
+			}
+		}
+
+		parent = s.stack[level]
+		if level == 0 {
+			// An unindented line after a named root starts another tree
+			parent = nil
+		}
+		node = &tree.Node{
+			Name:    name,
+			IsDir:   isDir,
+			Level:   level,
+			Line:    s.line,
+			Comment: comment,
+		}
+
+		if keyword, arg, ok := parseDirective(name); ok {
+			node.Directive = keyword
+			node.Name = arg
+			node.IsDir = false
+		}
+
+		if node.Directive != "" || isDir {
+			if node.Directive == "" {
+				// Only lines after the first trailing slash are guessed, so
+				// that a stream never has to revise an emitted node
+				if hasDirSuffix(line) {
+					s.explicit = true
+				} else {
+					guessed = s.explicit
+				}
+			}
+
+			// Add to stack for children
+			if level+1 < len(s.stack) {
+				s.stack[level+1] = node
+			} else {
+				s.stack = append(s.stack, node)
+			}
+		}
+		if parent == nil {
+			s.stack[0] = node
+		}
+
+		s.prevLevel = level
+		return node, parent, guessed, true
+	}
+}
+
+// pendingLine is a line read ahead, with its line number
+type pendingLine struct {
+	text   string
+	number int
+}
+
+// readLine returns the next line without its newline
+func (s *Scanner) readLine() (string, bool) {
+	if n := len(s.unread); n > 0 {
+		line := s.unread[n-1]
+		s.unread = s.unread[:n-1]
+		s.line = line.number
+		return line.text, true
+	}
+	if s.eof {
+		return "", false
+	}
+	line, err := s.r.ReadString('\n')
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			s.err = err
+			return "", false
+		}
+		s.eof = true
+		if line == "" {
+			return "", false
+		}
+	}
+	s.line++
+	return strings.TrimSuffix(line, "\n"), true
+}
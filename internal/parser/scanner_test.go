@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"github.com/neomen/buildtree/pkg/tree"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestScanner_MatchesParseInput(t *testing.T) {
+	inputs := []string{
+		`project/
+├── src/
+│   ├── main.go # entry point
+│   └── util
+└── README.md`,
+		`app
+|-- cmd
+|   '-- main.go
+'-- docs`,
+		`root/
+  a/
+    b.txt
+  c
+    d
+  @include x.tree`,
+		"\n\nlate/\n└── file.txt",
+		".\n├── src/\n│   └── main.go\n└── go.mod",
+		"\nmain.go\n\n\ngo.mod # module\nsrc/\n  util.go",
+		"├── src/\n│   └── main.go\n└── go.mod",
+		"project\n├── LICENSE\n├── NOTICE\n└── src/\n    └── Makefile",
+	}
+
+	for i, input := range inputs {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			root, err := ParseInput(input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var want []string
+			var walk func(node *tree.Node)
+			walk = func(node *tree.Node) {
+				want = append(want, describe(node))
+				for _, child := range node.Children {
+					walk(child)
+				}
+			}
+			walk(root)
+
+			var got []string
+			s := NewScanner(strings.NewReader(input))
+			for s.Scan() {
+				if s.Node().Children != nil {
+					t.Errorf("Scanned nodes should not hold children: %+v", s.Node())
+				}
+				got = append(got, describe(s.Node()))
+			}
+			if err := s.Err(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("Scanner and ParseInput disagree.\nScanner:\n%s\nParseInput:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestScanner_Parents(t *testing.T) {
+	s := NewScanner(strings.NewReader("a/\n├── b/\n│   └── c.txt\n└── d.txt"))
+
+	parents := map[string]string{}
+	for s.Scan() {
+		parent := ""
+		if s.Parent() != nil {
+			parent = s.Parent().Name
+		}
+		parents[s.Node().Name] = parent
+	}
+
+	expected := map[string]string{"a": "", "b": "a", "c.txt": "b", "d.txt": "a"}
+	for name, parent := range expected {
+		if parents[name] != parent {
+			t.Errorf("Expected parent of %s to be %q, got %q", name, parent, parents[name])
+		}
+	}
+}
+
+func TestScanner_SeveralRoots(t *testing.T) {
+	s := NewScanner(strings.NewReader("app/\n└── main.go\nlib/\n└── lib.go\nREADME.md"))
+
+	var got []string
+	for s.Scan() {
+		parent := ""
+		if s.Parent() != nil {
+			parent = s.Parent().Name
+		}
+		got = append(got, s.Node().Name+" < "+parent)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"app < ", "main.go < app", "lib < ", "lib.go < lib", "README.md < "}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestScanner_Errors(t *testing.T) {
+	for _, input := range []string{"", "  \n\n\t\n"} {
+		s := NewScanner(strings.NewReader(input))
+		if s.Scan() || !errors.Is(s.Err(), ErrEmptyInput) {
+			t.Errorf("%q: expected ErrEmptyInput, got %v", input, s.Err())
+		}
+	}
+
+	failure := errors.New("read failed")
+	s := NewScanner(io.MultiReader(strings.NewReader("a/\n├── b.txt\n"), &failingReader{failure}))
+	count := 0
+	for s.Scan() {
+		count++
+	}
+	if !errors.Is(s.Err(), failure) {
+		t.Errorf("Expected the read error, got %v", s.Err())
+	}
+	if count != 1 {
+		t.Errorf("Expected only the root before the error, got %d nodes", count)
+	}
+}
+
+func TestScanner_LargeInput(t *testing.T) {
+	const files = 100000
+	pr, pw := io.Pipe()
+	go func() {
+		fmt.Fprintln(pw, "big/")
+		for i := range files {
+			fmt.Fprintf(pw, "├── file%06d.txt\n", i)
+		}
+		pw.Close()
+	}()
+
+	s := NewScanner(pr)
+	count := 0
+	for s.Scan() {
+		count++
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != files+1 {
+		t.Errorf("Expected %d nodes, got %d", files+1, count)
+	}
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func describe(node *tree.Node) string {
+	return fmt.Sprintf("%s dir=%v level=%d line=%d comment=%q directive=%q", node.Name, node.IsDir, node.Level, node.Line, node.Comment, node.Directive)
+}
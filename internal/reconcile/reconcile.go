@@ -0,0 +1,282 @@
+package reconcile
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/neomen/buildtree/internal/diff"
+	"github.com/neomen/buildtree/internal/glob"
+	"github.com/neomen/buildtree/internal/validator"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// Action is what a step does to the filesystem
+type Action string
+
+const (
+	Create Action = "create" // Create a missing path
+	Remove Action = "remove" // Prune a path that is not in the spec
+	Skip   Action = "skip"   // Leave a difference in place
+)
+
+// DefaultProtect lists paths that are never pruned
+var DefaultProtect = []string{".git", "node_modules"}
+
+// Step is a single change needed to make a directory match the spec
+type Step struct {
+	Action Action
+	Path   string         // Slash-separated, relative to the root ("." is the root itself)
+	Type   diff.EntryType // Type of the path being created or removed
+	Node   *tree.Node     // Spec node of a created path
+	Reason string         // Why a difference is skipped
+}
+
+// Options controls which differences are reconciled
+type Options struct {
+	Prune   bool     // Remove paths that are not in the spec
+	Protect []string // Glob patterns of paths that are never pruned
+	Ignore  []string // Glob patterns of paths left out of the comparison
+}
+
+// Plan compares the spec with the directory and returns the steps that
+// make them match. A missing directory is planned to be created in full.
+func Plan(spec *tree.Node, dir string, opts Options) ([]Step, error) {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		steps := []Step{{Action: Create, Path: ".", Type: diff.Dir, Node: spec}}
+		for _, child := range spec.Children {
+			steps = appendCreate(steps, child, child.Name)
+		}
+		return steps, nil
+	}
+
+	changes, err := diff.Compare(spec, dir, diff.Options{Ignore: opts.Ignore})
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []Step
+	for _, change := range changes {
+		switch change.Kind {
+		case diff.Missing:
+			if reason := uncreatable(change.Path); reason != "" {
+				steps = append(steps, Step{Action: Skip, Path: change.Path, Type: change.Want, Reason: reason})
+				continue
+			}
+			steps = append(steps, Step{Action: Create, Path: change.Path, Type: change.Want, Node: change.Node})
+
+		case diff.Extra:
+			if !opts.Prune {
+				continue
+			}
+			reason, err := protection(dir, change.Path, opts.Protect)
+			if err != nil {
+				return nil, err
+			}
+			if reason != "" {
+				steps = append(steps, Step{Action: Skip, Path: change.Path, Type: change.Got, Reason: reason})
+				continue
+			}
+			steps = append(steps, Step{Action: Remove, Path: change.Path, Type: change.Got})
+
+		case diff.TypeMismatch:
+			reason := ""
+			if change.Path == "." {
+				reason = "root is not a directory"
+			} else if reason, err = protection(dir, change.Path, opts.Protect); err != nil {
+				return nil, err
+			}
+			if reason == "" && !opts.Prune {
+				reason = fmt.Sprintf("expected %s, found %s", change.Want, change.Got)
+			}
+			if reason != "" {
+				steps = append(steps, Step{Action: Skip, Path: change.Path, Type: change.Got, Reason: reason})
+				continue
+			}
+			steps = append(steps, Step{Action: Remove, Path: change.Path, Type: change.Got})
+			steps = appendCreate(steps, change.Node, change.Path)
+		}
+	}
+
+	return steps, nil
+}
+
+// appendCreate plans the creation of a node and everything under it
+func appendCreate(steps []Step, node *tree.Node, rel string) []Step {
+	step := Step{Action: Create, Path: rel, Type: diff.File, Node: node}
+	if node.IsDir {
+		step.Type = diff.Dir
+	}
+	if reason := uncreatable(rel); reason != "" {
+		step.Action = Skip
+		step.Reason = reason
+		return append(steps, step)
+	}
+
+	steps = append(steps, step)
+	for _, child := range node.Children {
+		steps = appendCreate(steps, child, path.Join(rel, child.Name))
+	}
+	return steps
+}
+
+// uncreatable explains why a spec path cannot be created, if it cannot
+func uncreatable(rel string) string {
+	for _, segment := range strings.Split(rel, "/") {
+		if glob.HasMeta(segment) {
+			return "wildcard"
+		}
+		if err := validator.Validate(segment); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// protection explains why the path at rel may not be removed: it is
+// protected itself, or a directory with a protected path somewhere inside
+func protection(dir, rel string, patterns []string) (string, error) {
+	if isProtected(patterns, rel) {
+		return "protected", nil
+	}
+
+	var inner string
+	root := filepath.Join(dir, filepath.FromSlash(rel))
+	err := filepath.WalkDir(root, func(p string, _ os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		sub, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if sub = filepath.ToSlash(sub); sub != rel && isProtected(patterns, sub) {
+			inner = sub
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if inner != "" {
+		return fmt.Sprintf("contains protected '%s'", inner), nil
+	}
+	return "", nil
+}
+
+// isProtected checks the base name, or the relative path for patterns containing a slash
+func isProtected(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		target := path.Base(rel)
+		if strings.Contains(pattern, "/") {
+			target = rel
+		}
+		if glob.Match(pattern, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply performs the steps in dir. Removed paths are moved under trash,
+// keeping their relative location, or deleted when trash is empty.
+// Removals happen before creations so that mismatched types can be replaced.
+func Apply(dir string, steps []Step, trash string) error {
+	for _, step := range steps {
+		if step.Action != Remove {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(step.Path))
+		if trash == "" {
+			if err := os.RemoveAll(target); err != nil {
+				return err
+			}
+			continue
+		}
+
+		dest := filepath.Join(trash, filepath.FromSlash(step.Path))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := move(target, dest); err != nil {
+			return err
+		}
+	}
+
+	for _, step := range steps {
+		if step.Action != Create {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(step.Path))
+		if step.Type == diff.Dir {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := createFile(target, step.Node.Content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// move renames src to dst, copying it instead when a rename is not
+// possible, such as from one file system to another
+func move(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// createFile writes a new file, never overwriting an existing one
+func createFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
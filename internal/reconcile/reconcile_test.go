@@ -0,0 +1,218 @@
+package reconcile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+func specTree() *tree.Node {
+	return &tree.Node{
+		Name:  "project",
+		IsDir: true,
+		Children: []*tree.Node{
+			{
+				Name:  "src",
+				IsDir: true,
+				Level: 1,
+				Children: []*tree.Node{
+					{Name: "main.go", Level: 2, Content: "package main\n"},
+				},
+			},
+			{Name: "config", IsDir: true, Level: 1},
+			{Name: "plugins", IsDir: true, Level: 1, Children: []*tree.Node{{Name: "*.so", Level: 2}}},
+		},
+	}
+}
+
+func TestPlan_CreateOnly(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "src/old.go", "config", "notes.txt")
+
+	steps, err := Plan(specTree(), dir, Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"create src/main.go",
+		"skip config",
+		"create plugins",
+		"skip plugins/*.so",
+	}
+	assertSteps(t, steps, expected)
+}
+
+func TestPlan_Prune(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "src/main.go", "src/old.go", "config", "plugins/a.so", ".git/HEAD", "node_modules/x/index.js", "keep.txt")
+
+	steps, err := Plan(specTree(), dir, Options{Prune: true, Protect: append(DefaultProtect, "*.txt")})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"remove src/old.go",
+		"remove config",
+		"create config",
+		"skip .git",
+		"skip keep.txt",
+		"skip node_modules",
+	}
+	assertSteps(t, steps, expected)
+}
+
+func TestPlan_PruneNestedProtected(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "src/main.go", "vendor/.git/HEAD", "vendor/lib/x.go", "tools/web/node_modules/m.js", "tmp/cache.bin")
+
+	steps, err := Plan(specTree(), dir, Options{Prune: true, Protect: DefaultProtect})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"create config",
+		"create plugins",
+		"skip plugins/*.so",
+		"remove tmp",
+		"skip tools",
+		"skip vendor",
+	}
+	assertSteps(t, steps, expected)
+	for _, step := range steps {
+		if step.Path == "vendor" && step.Reason != "contains protected 'vendor/.git'" {
+			t.Errorf("Unexpected reason %q for vendor", step.Reason)
+		}
+	}
+
+	if err := Apply(dir, steps, ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, kept := range []string{"vendor/.git/HEAD", "vendor/lib/x.go", "tools/web/node_modules/m.js"} {
+		if _, err := os.Stat(filepath.Join(dir, kept)); err != nil {
+			t.Errorf("Expected %s to be kept: %v", kept, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tmp")); !os.IsNotExist(err) {
+		t.Errorf("Expected tmp to be pruned, got %v", err)
+	}
+}
+
+func TestPlan_MissingRoot(t *testing.T) {
+	steps, err := Plan(specTree(), filepath.Join(t.TempDir(), "project"), Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"create .",
+		"create src",
+		"create src/main.go",
+		"create config",
+		"create plugins",
+		"skip plugins/*.so",
+	}
+	assertSteps(t, steps, expected)
+}
+
+func TestApply(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "src/old.go", "config", "src/main.go")
+	if err := os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("keep me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	trash := filepath.Join(t.TempDir(), "trash")
+
+	steps, err := Plan(specTree(), dir, Options{Prune: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := Apply(dir, steps, trash); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, "config")); err != nil || !info.IsDir() {
+		t.Error("config should have been replaced by a directory")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src", "old.go")); !os.IsNotExist(err) {
+		t.Error("src/old.go should have been pruned")
+	}
+	if _, err := os.Stat(filepath.Join(trash, "src", "old.go")); err != nil {
+		t.Errorf("src/old.go should be in the trash: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(trash, "config")); err != nil {
+		t.Errorf("config should be in the trash: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "src", "main.go"))
+	if err != nil || string(content) != "keep me" {
+		t.Errorf("Existing files must not be overwritten, got %q (%v)", content, err)
+	}
+}
+
+func TestApply_Delete(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "extra/file.txt")
+
+	steps, err := Plan(&tree.Node{Name: "project", IsDir: true}, dir, Options{Prune: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := Apply(dir, steps, ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "extra")); !os.IsNotExist(err) {
+		t.Error("extra should have been deleted")
+	}
+}
+
+func TestMove_Copies(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "extra")
+	writeFiles(t, src, "a.txt", "sub/b.txt")
+
+	// A non-empty destination makes the rename fail, like another file system
+	dst := t.TempDir()
+	writeFiles(t, dst, "other.txt")
+
+	if err := move(src, dst); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, file := range []string{"a.txt", "sub/b.txt", "other.txt"} {
+		if _, err := os.Stat(filepath.Join(dst, filepath.FromSlash(file))); err != nil {
+			t.Errorf("Expected %s in the destination: %v", file, err)
+		}
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("The source should be gone after the copy")
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertSteps(t *testing.T, steps []Step, expected []string) {
+	t.Helper()
+	var actual []string
+	for _, step := range steps {
+		actual = append(actual, string(step.Action)+" "+step.Path)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected steps %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,420 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/neomen/buildtree/internal/expand"
+	"github.com/neomen/buildtree/internal/format"
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/internal/utils"
+	"github.com/neomen/buildtree/internal/validator"
+	"github.com/neomen/buildtree/pkg/tree"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	metadataFile = "template.yaml" // Description and parameters of a template
+	specName     = "spec"          // Base name of the spec file, with any supported extension
+	filesDir     = "files"         // Optional file contents, laid out like the spec
+)
+
+// ErrNotFound is returned when a template does not exist in the store
+var ErrNotFound = errors.New("template not found")
+
+// Parameter is a variable that a template expects
+type Parameter struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Default     any    `yaml:"default,omitempty"` // Required when nil
+}
+
+// Metadata describes a template and its parameters
+type Metadata struct {
+	Description string      `yaml:"description,omitempty"`
+	Parameters  []Parameter `yaml:"parameters,omitempty"`
+}
+
+// Template is a named spec in the store
+type Template struct {
+	Name string
+	Dir  string
+	Metadata
+}
+
+// Store is a directory holding one subdirectory per template
+type Store struct {
+	Dir string
+}
+
+// DefaultDir returns the template directory under the user config directory
+func DefaultDir() (string, error) {
+	return utils.ConfigDir("templates")
+}
+
+// List returns all templates in the store, sorted by name
+func (s *Store) List() ([]*Template, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []*Template
+	for _, entry := range entries {
+		// Hidden directories are templates being added or replaced
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		tmpl, err := s.Get(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, nil
+}
+
+// Get loads the metadata of a template
+func (s *Store) Get(name string) (*Template, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(s.Dir, name)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return load(name, dir)
+}
+
+// load reads the metadata of the template stored in dir
+func load(name, dir string) (*Template, error) {
+	tmpl := &Template{Name: name, Dir: dir}
+	content, err := os.ReadFile(filepath.Join(dir, metadataFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(content, &tmpl.Metadata); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, metadataFile), err)
+	}
+	return tmpl, nil
+}
+
+// Add stores a template from either a spec file or a directory laid out
+// like a template. Parameters are detected from the spec's variables when
+// the metadata does not declare any. The template is prepared in a
+// staging directory, so that a failure leaves any template it replaces
+// untouched.
+func (s *Store) Add(name, source string, meta Metadata, force bool) (*Template, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(s.Dir, name)
+	_, err := os.Stat(dir)
+	exists := err == nil
+	if exists && !force {
+		return nil, fmt.Errorf("template '%s' already exists", name)
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return nil, err
+	}
+	staging, err := os.MkdirTemp(s.Dir, "."+name+".new-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	tmpl, err := prepare(name, staging, source, info.IsDir(), meta)
+	if err != nil {
+		return nil, err
+	}
+
+	// Swap the new template in, keeping the old one until it is in place
+	var old string
+	if exists {
+		if old, err = os.MkdirTemp(s.Dir, "."+name+".old-"); err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(old)
+		old = filepath.Join(old, name)
+		if err := os.Rename(dir, old); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		if old != "" {
+			os.Rename(old, dir)
+		}
+		return nil, err
+	}
+	tmpl.Dir = dir
+	return tmpl, nil
+}
+
+// prepare copies source into dir and completes the template's metadata
+func prepare(name, dir, source string, isDir bool, meta Metadata) (*Template, error) {
+	var err error
+	if isDir {
+		err = copyDir(source, dir)
+	} else {
+		err = copySpec(source, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := load(name, dir)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Description != "" {
+		tmpl.Description = meta.Description
+	}
+	if len(meta.Parameters) > 0 {
+		tmpl.Parameters = meta.Parameters
+	}
+
+	root, err := tmpl.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(tmpl.Parameters) == 0 {
+		names, err := expand.Variables(root)
+		if err != nil {
+			return nil, err
+		}
+		// Variables used only in @if conditions default to false
+		conditions, err := expand.Conditions(root)
+		if err != nil {
+			return nil, err
+		}
+		optional := map[string]bool{}
+		for _, name := range conditions {
+			optional[name] = true
+		}
+		for _, name := range names {
+			param := Parameter{Name: name}
+			if optional[name] {
+				param.Default = false
+			}
+			tmpl.Parameters = append(tmpl.Parameters, param)
+		}
+	}
+
+	if err := tmpl.save(); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// copySpec stores a spec file in dir. The files named by its @include
+// lines are not copied along, so a spec with includes is stored as a tree
+// diagram with the included specs spliced in.
+func copySpec(source, dir string) error {
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	root, err := format.Parse(format.Detect(source), string(content))
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+
+	includes := false
+	root.Walk(func(node *tree.Node, _ int) error {
+		includes = includes || node.Directive == parser.DirectiveInclude
+		return nil
+	}, nil)
+	if !includes {
+		ext := filepath.Ext(source)
+		if ext == "" {
+			ext = ".tree"
+		}
+		return os.WriteFile(filepath.Join(dir, specName+ext), content, 0644)
+	}
+
+	if err := parser.ResolveIncludes(root, source); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(dir, specName+".tree"))
+	if err != nil {
+		return err
+	}
+	if err := format.Render(format.Tree, f, root); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Remove deletes a template from the store
+func (s *Store) Remove(name string) error {
+	tmpl, err := s.Get(name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(tmpl.Dir)
+}
+
+// SpecFile returns the path of the template's spec file
+func (t *Template) SpecFile() (string, error) {
+	matches, err := filepath.Glob(filepath.Join(t.Dir, specName+".*"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("template '%s' has no %s file", t.Name, specName)
+	}
+	sort.Strings(matches)
+	return matches[0], nil
+}
+
+// Load parses the template's spec and attaches contents from its files directory
+func (t *Template) Load() (*tree.Node, error) {
+	specFile, err := t.SpecFile()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(specFile)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := format.Parse(format.Detect(specFile), string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", specFile, err)
+	}
+	if err := parser.ResolveIncludes(root, specFile); err != nil {
+		return nil, err
+	}
+
+	files := filepath.Join(t.Dir, filesDir)
+	err = filepath.WalkDir(files, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == files {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(files, path)
+		if err != nil {
+			return err
+		}
+		node := findPath(root, strings.Split(filepath.ToSlash(rel), "/"))
+		if node == nil || node.IsDir {
+			return fmt.Errorf("template '%s': %s does not match a file in the spec", t.Name, filepath.ToSlash(rel))
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		node.Content = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// Values fills in parameter defaults and reports required parameters
+// that have no value
+func (t *Template) Values(values expand.Values) (expand.Values, error) {
+	result := expand.Values{}
+	var missing []string
+	for _, param := range t.Parameters {
+		if param.Default != nil {
+			result[param.Name] = param.Default
+		} else if _, ok := values[param.Name]; !ok {
+			missing = append(missing, param.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required parameters: %s", strings.Join(missing, ", "))
+	}
+
+	result.Merge(values)
+	return result, nil
+}
+
+func (t *Template) save() error {
+	content, err := yaml.Marshal(&t.Metadata)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(t.Dir, metadataFile), content, 0644)
+}
+
+// findPath looks up a node by the names below the root
+func findPath(root *tree.Node, segments []string) *tree.Node {
+	node := root
+	for _, segment := range segments {
+		var next *tree.Node
+		for _, child := range node.Children {
+			if child.Name == segment {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+func checkName(name string) error {
+	if !validator.IsValidPath(name) || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid template name '%s'", name)
+	}
+	return nil
+}
+
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
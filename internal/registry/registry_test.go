@@ -0,0 +1,222 @@
+package registry
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/neomen/buildtree/internal/expand"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+const serviceSpec = `{{ .Name }}/
+├── cmd/
+│   └── main.go
+└── {{ .Name }}.yaml
+`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore_AddFromSpecFile(t *testing.T) {
+	store := &Store{Dir: filepath.Join(t.TempDir(), "templates")}
+	spec := filepath.Join(t.TempDir(), "service")
+	writeFile(t, spec, serviceSpec)
+
+	tmpl, err := store.Add("go-service", spec, Metadata{Description: "Go service"}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if tmpl.Description != "Go service" {
+		t.Errorf("Expected description, got %q", tmpl.Description)
+	}
+	if len(tmpl.Parameters) != 1 || tmpl.Parameters[0].Name != "Name" {
+		t.Errorf("Expected detected parameter Name, got %+v", tmpl.Parameters)
+	}
+	if _, err := os.Stat(filepath.Join(store.Dir, "go-service", "spec.tree")); err != nil {
+		t.Errorf("Spec should be stored as spec.tree: %v", err)
+	}
+
+	if _, err := store.Add("go-service", spec, Metadata{}, false); err == nil {
+		t.Error("Expected error when adding an existing template")
+	}
+	if _, err := store.Add("go-service", spec, Metadata{}, true); err != nil {
+		t.Errorf("Expected --force to replace the template: %v", err)
+	}
+
+	// Metadata survives a reload
+	loaded, err := store.Get("go-service")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(loaded.Parameters) != 1 {
+		t.Errorf("Expected parameters to be saved, got %+v", loaded.Parameters)
+	}
+}
+
+func TestStore_AddSpecWithInclude(t *testing.T) {
+	store := &Store{Dir: filepath.Join(t.TempDir(), "templates")}
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "parts", "ci.tree"), "ci/\n└── .github/\n    └── workflows/\n        └── test.yml\n")
+	spec := filepath.Join(src, "service.tree")
+	writeFile(t, spec, "{{ .Name }}/\n├── @include parts/ci.tree\n└── go.mod # module\n")
+
+	tmpl, err := store.Add("service", spec, Metadata{}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The source and its includes may change or go away after adding
+	if err := os.RemoveAll(src); err != nil {
+		t.Fatal(err)
+	}
+	root, err := tmpl.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error loading the template: %v", err)
+	}
+	var paths []string
+	root.Walk(func(node *tree.Node, _ int) error {
+		paths = append(paths, node.Path())
+		return nil
+	}, nil)
+	expected := []string{"{{ .Name }}", "{{ .Name }}/.github", "{{ .Name }}/.github/workflows", "{{ .Name }}/.github/workflows/test.yml", "{{ .Name }}/go.mod"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+	if len(tmpl.Parameters) != 1 || tmpl.Parameters[0].Name != "Name" {
+		t.Errorf("Expected detected parameter Name, got %+v", tmpl.Parameters)
+	}
+}
+
+func TestStore_ForceKeepsTemplateOnFailure(t *testing.T) {
+	store := &Store{Dir: filepath.Join(t.TempDir(), "templates")}
+	spec := filepath.Join(t.TempDir(), "spec.tree")
+	writeFile(t, spec, serviceSpec)
+	if _, err := store.Add("svc", spec, Metadata{Description: "original"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	broken := filepath.Join(t.TempDir(), "broken.tree")
+	writeFile(t, broken, "{{ .Name\n└── main.go\n")
+	if _, err := store.Add("svc", broken, Metadata{}, true); err == nil {
+		t.Fatal("Expected error for a broken spec")
+	}
+
+	tmpl, err := store.Get("svc")
+	if err != nil || tmpl.Description != "original" {
+		t.Fatalf("Expected the original template to be kept, got %+v (%v)", tmpl, err)
+	}
+	if _, err := tmpl.Load(); err != nil {
+		t.Errorf("Expected the original spec to load: %v", err)
+	}
+	entries, err := os.ReadDir(store.Dir)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Expected no staging directories left, got %v (%v)", entries, err)
+	}
+}
+
+func TestStore_AddFromDirectory(t *testing.T) {
+	store := &Store{Dir: t.TempDir()}
+	source := t.TempDir()
+	writeFile(t, filepath.Join(source, "spec.tree"), serviceSpec)
+	writeFile(t, filepath.Join(source, "template.yaml"), "description: With contents\nparameters:\n  - name: Name\n  - name: Port\n    default: 8080\n    description: Listen port\n")
+	writeFile(t, filepath.Join(source, "files", "cmd", "main.go"), "package main // port {{ .Port }}\n")
+
+	tmpl, err := store.Add("svc", source, Metadata{}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tmpl.Description != "With contents" || len(tmpl.Parameters) != 2 {
+		t.Errorf("Unexpected metadata %+v", tmpl.Metadata)
+	}
+
+	root, err := tmpl.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if content := root.Children[0].Children[0].Content; content != "package main // port {{ .Port }}\n" {
+		t.Errorf("Unexpected content %q", content)
+	}
+
+	values, err := tmpl.Values(expand.Values{"Name": "api"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if values["Port"] != 8080 || values["Name"] != "api" {
+		t.Errorf("Unexpected values %v", values)
+	}
+	if _, err := tmpl.Values(expand.Values{}); err == nil {
+		t.Error("Expected error for missing required parameter")
+	}
+}
+
+func TestStore_ContentWithoutSpecFile(t *testing.T) {
+	store := &Store{Dir: t.TempDir()}
+	source := t.TempDir()
+	writeFile(t, filepath.Join(source, "spec.tree"), serviceSpec)
+	writeFile(t, filepath.Join(source, "files", "missing.txt"), "x")
+
+	if _, err := store.Add("broken", source, Metadata{}, false); err == nil {
+		t.Error("Expected error for content that matches no file")
+	}
+	if _, err := os.Stat(filepath.Join(store.Dir, "broken")); !os.IsNotExist(err) {
+		t.Error("A failed add should not leave a template behind")
+	}
+}
+
+func TestStore_ListAndRemove(t *testing.T) {
+	store := &Store{Dir: filepath.Join(t.TempDir(), "missing")}
+
+	templates, err := store.List()
+	if err != nil || len(templates) != 0 {
+		t.Fatalf("Expected empty list for a missing store, got %v (%v)", templates, err)
+	}
+
+	spec := filepath.Join(t.TempDir(), "spec.tree")
+	writeFile(t, spec, serviceSpec)
+	for _, name := range []string{"b", "a"} {
+		if _, err := store.Add(name, spec, Metadata{}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templates, err = store.List()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(templates) != 2 || templates[0].Name != "a" {
+		t.Errorf("Expected sorted templates, got %v", templates)
+	}
+
+	if err := store.Remove("a"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := store.Get("a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if err := store.Remove("../etc"); err == nil {
+		t.Error("Expected error for invalid name")
+	}
+}
+
+func TestDefaultDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/config")
+
+	dir, err := DefaultDir()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := filepath.Join("/config", "buildtree", "templates"); dir != expected {
+		t.Errorf("Expected %s, got %s", expected, dir)
+	}
+}
@@ -0,0 +1,140 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// Style selects the glyph set used to draw tree branches
+type Style string
+
+const (
+	StyleUnicode  Style = "unicode"  // ├──, └──, │
+	StyleASCII    Style = "ascii"    // |--, '--, |
+	StyleIndented Style = "indented" // Plain indentation without glyphs
+)
+
+// DefaultIndent is the number of columns each nesting level is indented by
+const DefaultIndent = 4
+
+// Options controls the appearance of a rendered tree.
+// The zero value renders the canonical style.
+type Options struct {
+	Style         Style // Glyph set (default unicode)
+	Indent        int   // Columns per level, at least 2 (default 4)
+	Sort          bool  // Order directories first, then by name
+	Comments      bool  // Keep "#" comments attached to nodes
+	TrimDirSuffix bool  // Omit the trailing slash on directories
+}
+
+// glyphs holds the prefixes for one level of a rendered tree
+type glyphs struct {
+	branch, last, pipe, blank string
+}
+
+// Tree writes the tree as a diagram in the syntax accepted by parser.ParseInput.
+// Directories are written with a trailing slash unless TrimDirSuffix is set,
+// so that no name heuristics are needed to read the diagram back.
+func Tree(w io.Writer, root *tree.Node, opts Options) error {
+	g, err := newGlyphs(opts)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	writeLine(bw, "", root, opts)
+	writeChildren(bw, root, "", g, opts)
+
+	return bw.Flush()
+}
+
+func newGlyphs(opts Options) (glyphs, error) {
+	indent := opts.Indent
+	if indent == 0 {
+		indent = DefaultIndent
+	}
+	if indent < 2 {
+		return glyphs{}, fmt.Errorf("indent must be at least 2, got %d", indent)
+	}
+
+	var branch, last, line, pipe string
+	switch opts.Style {
+	case "", StyleUnicode:
+		branch, last, line, pipe = "├", "└", "─", "│"
+	case StyleASCII:
+		branch, last, line, pipe = "|", "'", "-", "|"
+	case StyleIndented:
+		spaces := strings.Repeat(" ", indent)
+		return glyphs{branch: spaces, last: spaces, pipe: spaces, blank: spaces}, nil
+	default:
+		return glyphs{}, fmt.Errorf("unknown style %q", opts.Style)
+	}
+
+	dashes := strings.Repeat(line, indent-2)
+	return glyphs{
+		branch: branch + dashes + " ",
+		last:   last + dashes + " ",
+		pipe:   pipe + strings.Repeat(" ", indent-1),
+		blank:  strings.Repeat(" ", indent),
+	}, nil
+}
+
+func writeChildren(w *bufio.Writer, node *tree.Node, prefix string, g glyphs, opts Options) {
+	children := node.Children
+	if opts.Sort {
+		children = sortedChildren(children)
+	}
+
+	for i, child := range children {
+		branch, indent := g.branch, g.pipe
+		if i == len(children)-1 {
+			branch, indent = g.last, g.blank
+		}
+
+		writeLine(w, prefix+branch, child, opts)
+
+		// Directive lines hold the body of their @if / @each block
+		if child.IsDir || child.Directive != "" {
+			writeChildren(w, child, prefix+indent, g, opts)
+		}
+	}
+}
+
+func writeLine(w *bufio.Writer, prefix string, node *tree.Node, opts Options) {
+	w.WriteString(prefix)
+	if node.Directive != "" {
+		w.WriteString("@" + node.Directive + " ")
+	}
+	w.WriteString(node.Name)
+	if node.IsDir && !opts.TrimDirSuffix {
+		w.WriteString("/")
+	}
+	if opts.Comments && node.Comment != "" {
+		w.WriteString(" # ")
+		w.WriteString(node.Comment)
+	}
+	w.WriteString("\n")
+}
+
+// sortedChildren returns a copy ordered with directories first, then by name
+func sortedChildren(children []*tree.Node) []*tree.Node {
+	sorted := make([]*tree.Node, len(children))
+	copy(sorted, children)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].IsDir != sorted[j].IsDir {
+			return sorted[i].IsDir
+		}
+		a, b := strings.ToLower(sorted[i].Name), strings.ToLower(sorted[j].Name)
+		if a != b {
+			return a < b
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -0,0 +1,214 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+func TestTree_Simple(t *testing.T) {
+	root := &tree.Node{
+		Name:  "project",
+		IsDir: true,
+		Children: []*tree.Node{
+			{
+				Name:  "src",
+				IsDir: true,
+				Level: 1,
+				Children: []*tree.Node{
+					{Name: "main.go", Level: 2},
+					{Name: "util", IsDir: true, Level: 2},
+				},
+			},
+			{Name: "Makefile", Level: 1},
+		},
+	}
+
+	expected := `project/
+├── src/
+│   ├── main.go
+│   └── util/
+└── Makefile
+`
+
+	var buf bytes.Buffer
+	if err := Tree(&buf, root, Options{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != expected {
+		t.Errorf("Unexpected output.\nExpected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
+
+func TestTree_RoundTrip(t *testing.T) {
+	input := `app/
+├── cmd/
+│   └── app/
+│       └── main.go
+├── docs/
+├── internal/
+│   ├── LICENSE
+│   └── server.go
+├── Makefile
+└── go.mod
+`
+
+	root, err := parser.ParseInput(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Tree(&buf, root, Options{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != input {
+		t.Errorf("Round trip changed the diagram.\nExpected:\n%s\nGot:\n%s", input, buf.String())
+	}
+}
+
+func TestTree_Styles(t *testing.T) {
+	input := `project/ # root
+├── src/ # sources
+│   └── main.go
+└── go.mod
+`
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{
+			name: "ASCII",
+			opts: Options{Style: StyleASCII},
+			expected: `project/
+|-- src/
+|   '-- main.go
+'-- go.mod
+`,
+		},
+		{
+			name: "Indent 2 with comments",
+			opts: Options{Indent: 2, Comments: true},
+			expected: `project/ # root
+├ src/ # sources
+│ └ main.go
+└ go.mod
+`,
+		},
+		{
+			name: "Indent 3 sorted",
+			opts: Options{Indent: 3, Sort: true},
+			expected: `project/
+├─ src/
+│  └─ main.go
+└─ go.mod
+`,
+		},
+		{
+			name: "Without directory suffix",
+			opts: Options{TrimDirSuffix: true},
+			expected: `project
+├── src
+│   └── main.go
+└── go.mod
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parser.ParseInput(input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := Tree(&buf, root, tt.opts); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Fatalf("Unexpected output.\nExpected:\n%s\nGot:\n%s", tt.expected, buf.String())
+			}
+
+			// Every style must parse back to the same canonical tree
+			reparsed, err := parser.ParseInput(buf.String())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var canonical bytes.Buffer
+			if err := Tree(&canonical, reparsed, Options{Comments: true}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var original bytes.Buffer
+			Tree(&original, root, Options{Comments: tt.opts.Comments})
+			if canonical.String() != original.String() {
+				t.Errorf("Re-parsed tree differs.\nExpected:\n%s\nGot:\n%s", original.String(), canonical.String())
+			}
+		})
+	}
+}
+
+func TestTree_Sort(t *testing.T) {
+	root := &tree.Node{
+		Name:  "project",
+		IsDir: true,
+		Children: []*tree.Node{
+			{Name: "b.txt", Level: 1},
+			{Name: "Zeta", IsDir: true, Level: 1},
+			{Name: "A.txt", Level: 1},
+			{Name: "alpha", IsDir: true, Level: 1},
+		},
+	}
+
+	expected := `project/
+├── alpha/
+├── Zeta/
+├── A.txt
+└── b.txt
+`
+
+	var buf bytes.Buffer
+	if err := Tree(&buf, root, Options{Sort: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != expected {
+		t.Errorf("Unexpected output.\nExpected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+	if root.Children[0].Name != "b.txt" {
+		t.Error("Sorting should not modify the tree")
+	}
+}
+
+func TestTree_InvalidOptions(t *testing.T) {
+	root := &tree.Node{Name: "project", IsDir: true}
+
+	if err := Tree(&bytes.Buffer{}, root, Options{Indent: 1}); err == nil {
+		t.Error("Expected error for indent 1")
+	}
+	if err := Tree(&bytes.Buffer{}, root, Options{Style: "fancy"}); err == nil {
+		t.Error("Expected error for unknown style")
+	}
+}
+
+func TestTree_Directives(t *testing.T) {
+	input := `project/
+├── @include shared/ci.tree
+└── main.go
+`
+	root, err := parser.ParseInput(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Tree(&buf, root, Options{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != input {
+		t.Errorf("Directive not preserved.\nExpected:\n%s\nGot:\n%s", input, buf.String())
+	}
+}
@@ -0,0 +1,189 @@
+package scanner
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/neomen/buildtree/internal/glob"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// Options controls which entries are included in a scan
+type Options struct {
+	MaxDepth  int      // Maximum depth to descend (0 = no limit)
+	Hidden    bool     // Include entries whose names start with a dot
+	Ignore    []string // Glob patterns of entries to skip
+	GitIgnore bool     // Honor .gitignore files found in scanned directories
+}
+
+// ignoreRule is a single pattern read from a .gitignore file
+type ignoreRule struct {
+	base    string // Directory containing the .gitignore, relative to the scan root
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// Scan walks an existing directory and returns it as a tree.
+// The root node is named after the directory itself.
+func Scan(dir string, opts Options) (*tree.Node, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &os.PathError{Op: "scan", Path: dir, Err: os.ErrInvalid}
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &tree.Node{
+		Name:  filepath.Base(abs),
+		IsDir: true,
+		Level: 0,
+	}
+
+	if err := scanDir(root, dir, "", opts, nil); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func scanDir(node *tree.Node, dir, rel string, opts Options, rules []ignoreRule) error {
+	if opts.MaxDepth > 0 && node.Level >= opts.MaxDepth {
+		return nil
+	}
+
+	if opts.GitIgnore {
+		loaded, err := loadGitIgnore(filepath.Join(dir, ".gitignore"), rel)
+		if err != nil {
+			return err
+		}
+		rules = append(rules[:len(rules):len(rules)], loaded...)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		childRel := path.Join(rel, name)
+		isDir := entry.IsDir()
+
+		if !opts.Hidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		if opts.GitIgnore && (name == ".git" || isIgnored(rules, childRel, isDir)) {
+			continue
+		}
+		if matchesAny(opts.Ignore, name, childRel) {
+			continue
+		}
+
+		child := &tree.Node{
+			Name:  name,
+			IsDir: isDir,
+			Level: node.Level + 1,
+		}
+		node.Children = append(node.Children, child)
+
+		if isDir {
+			if err := scanDir(child, filepath.Join(dir, name), childRel, opts, rules); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchesAny checks the base name, or the relative path for patterns containing a slash
+func matchesAny(patterns []string, name, rel string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(pattern, "/")
+		target := name
+		if strings.Contains(pattern, "/") {
+			target = rel
+		}
+		if glob.Match(pattern, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadGitIgnore(file, base string) ([]ignoreRule, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		// Patterns without an inner slash match at any depth
+		if strings.HasPrefix(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		if line == "" || line == "**/" {
+			continue
+		}
+
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+// isIgnored applies the rules in order; the last matching rule wins
+func isIgnored(rules []ignoreRule, rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		target := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			target = strings.TrimPrefix(rel, rule.base+"/")
+		}
+
+		if glob.Match(rule.pattern, target) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,167 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+func TestScan_Structure(t *testing.T) {
+	dir := makeTree(t, map[string]string{
+		"src/main.go":    "",
+		"src/util/a.go":  "",
+		"README.md":      "",
+		"Makefile":       "",
+		".hidden/secret": "",
+		".env":           "",
+	})
+
+	root, err := Scan(dir, Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if root.Name != filepath.Base(dir) || !root.IsDir {
+		t.Errorf("Unexpected root %q (dir=%v)", root.Name, root.IsDir)
+	}
+
+	assertPaths(t, root, []string{"Makefile", "README.md", "src/", "src/main.go", "src/util/", "src/util/a.go"})
+
+	makefile := findChild(root, "Makefile")
+	if makefile == nil || makefile.IsDir || makefile.Level != 1 {
+		t.Errorf("Makefile should be a file at level 1, got %+v", makefile)
+	}
+}
+
+func TestScan_Hidden(t *testing.T) {
+	dir := makeTree(t, map[string]string{
+		".env":           "",
+		".hidden/secret": "",
+		"main.go":        "",
+	})
+
+	root, err := Scan(dir, Options{Hidden: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assertPaths(t, root, []string{".env", ".hidden/", ".hidden/secret", "main.go"})
+}
+
+func TestScan_MaxDepth(t *testing.T) {
+	dir := makeTree(t, map[string]string{
+		"a/b/c/file.txt": "",
+	})
+
+	root, err := Scan(dir, Options{MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assertPaths(t, root, []string{"a/", "a/b/"})
+}
+
+func TestScan_Ignore(t *testing.T) {
+	dir := makeTree(t, map[string]string{
+		"src/main.go":      "",
+		"src/main_test.go": "",
+		"vendor/lib.go":    "",
+	})
+
+	root, err := Scan(dir, Options{Ignore: []string{"*_test.go", "vendor/"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assertPaths(t, root, []string{"src/", "src/main.go"})
+}
+
+func TestScan_GitIgnore(t *testing.T) {
+	dir := makeTree(t, map[string]string{
+		".gitignore":       "*.log\nbuild/\n!keep.log\n/top.txt\n",
+		".git/HEAD":        "",
+		"app.log":          "",
+		"keep.log":         "",
+		"top.txt":          "",
+		"build/out":        "",
+		"src/top.txt":      "",
+		"src/debug.log":    "",
+		"src/.gitignore":   "generated.go\n",
+		"src/generated.go": "",
+		"src/main.go":      "",
+	})
+
+	root, err := Scan(dir, Options{Hidden: true, GitIgnore: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assertPaths(t, root, []string{".gitignore", "keep.log", "src/", "src/.gitignore", "src/main.go", "src/top.txt"})
+}
+
+func TestScan_NotADirectory(t *testing.T) {
+	dir := makeTree(t, map[string]string{"file.txt": ""})
+
+	if _, err := Scan(filepath.Join(dir, "file.txt"), Options{}); err == nil {
+		t.Error("Expected error when scanning a file")
+	}
+	if _, err := Scan(filepath.Join(dir, "missing"), Options{}); err == nil {
+		t.Error("Expected error when scanning a missing path")
+	}
+}
+
+// makeTree creates files (and their parent directories) under a temp dir
+func makeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// assertPaths compares the tree with a list of slash paths, directories ending in "/"
+func assertPaths(t *testing.T, root *tree.Node, expected []string) {
+	t.Helper()
+	var actual []string
+	var walk func(node *tree.Node, prefix string)
+	walk = func(node *tree.Node, prefix string) {
+		for _, child := range node.Children {
+			path := prefix + child.Name
+			if child.IsDir {
+				actual = append(actual, path+"/")
+				walk(child, path+"/")
+			} else {
+				actual = append(actual, path)
+			}
+		}
+	}
+	walk(root, "")
+
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected paths %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Expected paths %v, got %v", expected, actual)
+			return
+		}
+	}
+}
+
+func findChild(parent *tree.Node, name string) *tree.Node {
+	for _, child := range parent.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
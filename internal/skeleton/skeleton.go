@@ -0,0 +1,142 @@
+package skeleton
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/neomen/buildtree/internal/utils"
+)
+
+// builtin holds the default skeletons, keyed by extension
+var builtin = map[string]Skeleton{
+	".go":   {Text: "package {{ .Package }}\n"},
+	".py":   {Text: "\"\"\"{{ .Stem }} module.\"\"\"\n"},
+	".json": {Text: "{}\n"},
+	".sh":   {Text: "#!/usr/bin/env bash\n", Executable: true},
+}
+
+// Skeleton is the initial content of a new file
+type Skeleton struct {
+	Text       string // text/template source, see Data
+	Executable bool
+}
+
+// Data is passed to skeleton templates
+type Data struct {
+	Name    string // File name, e.g. "server.go"
+	Stem    string // File name without extension, e.g. "server"
+	Dir     string // Name of the parent directory
+	Package string // Go package name: "main" for main.go, else derived from Dir
+}
+
+// Provider fills empty files from skeletons. Dirs are searched in order
+// before the built-in defaults. A skeleton directory holds files named
+// after a file name ("Makefile", "package.json") or "default" plus an
+// extension ("default.go"); exact names win over extensions, and the
+// executable bit of a skeleton file is kept.
+type Provider struct {
+	Dirs []string
+}
+
+// DefaultDir returns the skeleton directory under the user config directory
+func DefaultDir() (string, error) {
+	return utils.ConfigDir("skeletons")
+}
+
+// Content returns the rendered skeleton for the file at path and its
+// permissions, or "" when no skeleton applies
+func (p *Provider) Content(path string) (string, fs.FileMode, error) {
+	skeleton, ok, err := p.Lookup(filepath.Base(path))
+	if err != nil || !ok {
+		return "", 0, err
+	}
+
+	tmpl, err := template.New(path).Option("missingkey=error").Parse(skeleton.Text)
+	if err != nil {
+		return "", 0, fmt.Errorf("skeleton for %s: %w", path, err)
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, newData(path)); err != nil {
+		return "", 0, fmt.Errorf("skeleton for %s: %w", path, err)
+	}
+
+	mode := fs.FileMode(0644)
+	if skeleton.Executable {
+		mode = 0755
+	}
+	return out.String(), mode, nil
+}
+
+// Lookup finds the skeleton for a file name
+func (p *Provider) Lookup(name string) (Skeleton, bool, error) {
+	ext := filepath.Ext(name)
+	candidates := []string{name}
+	if ext != "" && ext != name {
+		candidates = append(candidates, "default"+ext)
+	}
+
+	for _, dir := range p.Dirs {
+		for _, candidate := range candidates {
+			path := filepath.Join(dir, candidate)
+			info, err := os.Stat(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return Skeleton{}, false, err
+			}
+			if info.IsDir() {
+				continue
+			}
+
+			text, err := os.ReadFile(path)
+			if err != nil {
+				return Skeleton{}, false, err
+			}
+			return Skeleton{Text: string(text), Executable: info.Mode()&0111 != 0}, true, nil
+		}
+	}
+
+	skeleton, ok := builtin[ext]
+	return skeleton, ok, nil
+}
+
+func newData(path string) Data {
+	name := filepath.Base(path)
+	dir := filepath.Base(filepath.Dir(path))
+	if abs, err := filepath.Abs(filepath.Dir(path)); err == nil {
+		dir = filepath.Base(abs)
+	}
+
+	data := Data{
+		Name:    name,
+		Stem:    strings.TrimSuffix(name, filepath.Ext(name)),
+		Dir:     dir,
+		Package: packageName(dir),
+	}
+	if name == "main.go" {
+		data.Package = "main"
+	}
+	return data
+}
+
+// packageName turns a directory name into a Go package name by lowercasing
+// it and dropping characters that are not letters or digits
+func packageName(dir string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(dir) {
+		if unicode.IsLetter(r) || (unicode.IsDigit(r) && b.Len() > 0) || (r == '_' && b.Len() > 0) {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "main"
+	}
+	return b.String()
+}
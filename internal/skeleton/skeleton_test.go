@@ -0,0 +1,91 @@
+package skeleton
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProvider_Builtin(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		mode    fs.FileMode
+	}{
+		{"billing/server.go", "package billing\n", 0644},
+		{"cmd/my-tool/main.go", "package main\n", 0644},
+		{"internal/Http-API/client.go", "package httpapi\n", 0644},
+		{"app/utils.py", "\"\"\"utils module.\"\"\"\n", 0644},
+		{"web/package.json", "{}\n", 0644},
+		{"scripts/deploy.sh", "#!/usr/bin/env bash\n", 0755},
+		{"docs/README.md", "", 0},
+		{"Makefile", "", 0},
+	}
+
+	p := &Provider{}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			content, mode, err := p.Content(tt.path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if content != tt.content || mode != tt.mode {
+				t.Errorf("Expected %q (%v), got %q (%v)", tt.content, tt.mode, content, mode)
+			}
+		})
+	}
+}
+
+func TestProvider_Dirs(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	writeSkeleton(t, first, "default.go", "// Package {{ .Package }} is {{ .Stem }}.\npackage {{ .Package }}\n", 0644)
+	writeSkeleton(t, first, "package.json", "{\"name\": \"{{ .Dir }}\"}\n", 0644)
+	writeSkeleton(t, second, "default.go", "package ignored\n", 0644)
+	writeSkeleton(t, second, "Makefile", "all:\n", 0644)
+	writeSkeleton(t, second, "default.py", "#!/usr/bin/env python3\n", 0755)
+
+	p := &Provider{Dirs: []string{first, second, filepath.Join(t.TempDir(), "missing")}}
+	tests := []struct {
+		path    string
+		content string
+		mode    fs.FileMode
+	}{
+		{"api/server.go", "// Package api is server.\npackage api\n", 0644},
+		{"web/package.json", "{\"name\": \"web\"}\n", 0644},
+		{"other.json", "{}\n", 0644},
+		{"Makefile", "all:\n", 0644},
+		{"tool.py", "#!/usr/bin/env python3\n", 0755},
+	}
+	for _, tt := range tests {
+		content, mode, err := p.Content(tt.path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.path, err)
+		}
+		if content != tt.content || mode != tt.mode {
+			t.Errorf("%s: expected %q (%v), got %q (%v)", tt.path, tt.content, tt.mode, content, mode)
+		}
+	}
+}
+
+func TestProvider_InvalidSkeleton(t *testing.T) {
+	dir := t.TempDir()
+	writeSkeleton(t, dir, "default.go", "package {{ .Unknown }}\n", 0644)
+
+	p := &Provider{Dirs: []string{dir}}
+	if _, _, err := p.Content("main.go"); err == nil {
+		t.Error("Expected error for unknown field in skeleton")
+	}
+}
+
+func writeSkeleton(t *testing.T, dir, name, content string, mode fs.FileMode) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
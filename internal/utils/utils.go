@@ -1,5 +1,10 @@
 package utils
 
+import (
+	"os"
+	"path/filepath"
+)
+
 // IsTreeSymbol checks if a rune is a tree diagram symbol
 func IsTreeSymbol(r rune) bool {
 	switch r {
@@ -9,3 +14,16 @@ func IsTreeSymbol(r rune) bool {
 		return false
 	}
 }
+
+// ConfigDir returns the buildtree directory name under $XDG_CONFIG_HOME,
+// or under the platform's user config directory when it is not set
+func ConfigDir(name string) (string, error) {
+	config := os.Getenv("XDG_CONFIG_HOME")
+	if config == "" {
+		var err error
+		if config, err = os.UserConfigDir(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(config, "buildtree", name), nil
+}
@@ -0,0 +1,103 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/neomen/buildtree/pkg/tree"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// ErrDuplicate is the cause of a name listed twice in a directory
+	ErrDuplicate = errors.New("duplicate name")
+	// ErrCaseCollision is the cause of names that differ only in case,
+	// which clash on macOS and Windows
+	ErrCaseCollision = errors.New("case-insensitive collision")
+	// ErrNormalizationCollision is the cause of names that differ only in
+	// Unicode normalization (NFC/NFD), which clash on macOS
+	ErrNormalizationCollision = errors.New("Unicode normalization collision")
+)
+
+// CollisionError is a name that clashes with an earlier sibling
+type CollisionError struct {
+	Path  string // Slash-separated path of the later node, from the root
+	Other string // Name of the earlier sibling it clashes with
+	Line  int    // Line of the later node, if known
+	File  string // Spec file of the later node, if known
+	Cause error  // ErrDuplicate, ErrCaseCollision or ErrNormalizationCollision
+}
+
+func (e *CollisionError) Error() string {
+	msg := fmt.Sprintf("%s: %v with '%s'", e.Path, e.Cause, e.Other)
+	switch {
+	case e.File != "":
+		return fmt.Sprintf("%s (%s:%d)", msg, e.File, e.Line)
+	case e.Line > 0:
+		return fmt.Sprintf("%s (line %d)", msg, e.Line)
+	}
+	return msg
+}
+
+func (e *CollisionError) Unwrap() error {
+	return e.Cause
+}
+
+// Collisions returns the siblings in the tree whose names clash with an
+// earlier sibling, in tree order. Exact duplicates are reported before
+// names that only match once normalized to NFC, and those before names
+// that only match regardless of case.
+func Collisions(root *tree.Node) []*CollisionError {
+	var collisions []*CollisionError
+	var paths []string
+	root.Walk(func(node *tree.Node, depth int) error {
+		paths = append(paths[:depth], node.Name)
+		if len(node.Children) < 2 {
+			return nil
+		}
+
+		exact := map[string]string{}
+		normalized := map[string]string{}
+		folded := map[string]string{}
+		for _, child := range node.Children {
+			if child.Directive != "" {
+				continue
+			}
+			nfc := norm.NFC.String(child.Name)
+			fold := strings.ToLower(nfc)
+
+			var other string
+			var cause error
+			if name, ok := exact[child.Name]; ok {
+				other, cause = name, ErrDuplicate
+			} else if name, ok := normalized[nfc]; ok {
+				other, cause = name, ErrNormalizationCollision
+			} else if name, ok := folded[fold]; ok {
+				other, cause = name, ErrCaseCollision
+			}
+			if cause != nil {
+				collisions = append(collisions, &CollisionError{
+					Path:  path.Join(append(paths[:depth+1:depth+1], child.Name)...),
+					Other: other,
+					Line:  child.Line,
+					File:  child.File,
+					Cause: cause,
+				})
+			}
+			addFirst(exact, child.Name, child.Name)
+			addFirst(normalized, nfc, child.Name)
+			addFirst(folded, fold, child.Name)
+		}
+		return nil
+	}, nil)
+	return collisions
+}
+
+// addFirst records name under key unless an earlier sibling holds it
+func addFirst(names map[string]string, key, name string) {
+	if _, ok := names[key]; !ok {
+		names[key] = name
+	}
+}
@@ -0,0 +1,75 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/neomen/buildtree/internal/parser"
+)
+
+func TestCollisions(t *testing.T) {
+	spec := "app/\n" +
+		"├── README.md\n" +
+		"├── readme.md\n" +
+		"├── main.go\n" +
+		"├── main.go\n" +
+		"├── docs/\n" +
+		"│   ├── caf\u00e9.md\n" +
+		"│   ├── cafe\u0301.md\n" +
+		"│   ├── CAF\u00c9.md\n" +
+		"│   └── Guide.md\n" +
+		"└── guide.md"
+	root, err := parser.ParseInput(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		other string
+		line  int
+		cause error
+	}{
+		{"app/readme.md", "README.md", 3, ErrCaseCollision},
+		{"app/main.go", "main.go", 5, ErrDuplicate},
+		{"app/docs/cafe\u0301.md", "caf\u00e9.md", 8, ErrNormalizationCollision},
+		{"app/docs/CAF\u00c9.md", "caf\u00e9.md", 9, ErrCaseCollision},
+	}
+
+	collisions := Collisions(root)
+	if len(collisions) != len(tests) {
+		t.Fatalf("Expected %d collisions, got %d: %v", len(tests), len(collisions), collisions)
+	}
+	for i, tt := range tests {
+		c := collisions[i]
+		if c.Path != tt.path || c.Other != tt.other || c.Line != tt.line || !errors.Is(c, tt.cause) {
+			t.Errorf("Expected %s %v with %s on line %d, got %v", tt.path, tt.cause, tt.other, tt.line, c)
+		}
+	}
+}
+
+func TestCollisions_None(t *testing.T) {
+	root, err := parser.ParseInput("app/\n├── src/\n│   └── main.go\n├── main.go\n└── Main.java")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if collisions := Collisions(root); len(collisions) != 0 {
+		t.Errorf("Expected no collisions, got %v", collisions)
+	}
+}
+
+func TestCollisionError_Error(t *testing.T) {
+	tests := []struct {
+		err      *CollisionError
+		expected string
+	}{
+		{&CollisionError{Path: "app/a.md", Other: "A.md", Cause: ErrCaseCollision}, "app/a.md: case-insensitive collision with 'A.md'"},
+		{&CollisionError{Path: "app/a.md", Other: "a.md", Line: 4, Cause: ErrDuplicate}, "app/a.md: duplicate name with 'a.md' (line 4)"},
+		{&CollisionError{Path: "app/a.md", Other: "a.md", Line: 4, File: "base.tree", Cause: ErrDuplicate}, "app/a.md: duplicate name with 'a.md' (base.tree:4)"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
@@ -1,62 +1,145 @@
 package validator
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 )
 
+// MaxNameLength is the longest name accepted, in characters
+const MaxNameLength = 255
+
+// ViolationKind identifies a rule a name breaks
+type ViolationKind string
+
+const (
+	ForbiddenChar ViolationKind = "forbidden-char" // A character or "//" not allowed in names
+	ReservedName  ViolationKind = "reserved-name"  // A device name reserved on Windows
+	TooLong       ViolationKind = "too-long"       // Longer than MaxNameLength
+	DotSegment    ViolationKind = "dot-segment"    // A "." or ".." path segment
+	Blank         ViolationKind = "blank"          // Empty or whitespace-only
+)
+
+// Violation is one reason a name is not a valid path
+type Violation struct {
+	Kind     ViolationKind `json:"kind"`
+	Value    string        `json:"value,omitempty"`    // Offending character, segment or reserved name
+	Position int           `json:"position,omitempty"` // 1-based character position of Value in the name
+	Length   int           `json:"length,omitempty"`   // Length of the name, for TooLong
+}
+
+func (v Violation) String() string {
+	switch v.Kind {
+	case ForbiddenChar:
+		if utf8.RuneCountInString(v.Value) > 1 {
+			return fmt.Sprintf("forbidden sequence '%s' at position %d", v.Value, v.Position)
+		}
+		return fmt.Sprintf("forbidden character '%s' at position %d", v.Value, v.Position)
+	case ReservedName:
+		return fmt.Sprintf("reserved Windows name '%s'", v.Value)
+	case TooLong:
+		return fmt.Sprintf("too long (%d characters, max %d)", v.Length, MaxNameLength)
+	case DotSegment:
+		return fmt.Sprintf("dot segment '%s' at position %d", v.Value, v.Position)
+	case Blank:
+		return "empty or whitespace-only"
+	}
+	return string(v.Kind)
+}
+
+// NameError reports the violations of a name that is not a valid path
+type NameError struct {
+	Name       string
+	Violations []Violation
+}
+
+func (e *NameError) Error() string {
+	reasons := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		reasons[i] = v.String()
+	}
+	return "invalid name: " + strings.Join(reasons, ", ")
+}
+
+// forbiddenChars may not appear anywhere in a name
+const forbiddenChars = `\:*?"<>|`
+
+// reservedNames are device names on Windows, in any case
+var reservedNames = []string{"CON", "PRN", "AUX", "NUL", "COM1", "COM2", "COM3", "COM4", "COM5",
+	"COM6", "COM7", "COM8", "COM9", "LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9"}
+
 // IsValidPath checks if a path is safe and valid
 func IsValidPath(name string) bool {
-	// Removing the ending slash for verification
-	cleanName := strings.TrimSuffix(name, "/")
-	cleanName = strings.TrimSpace(cleanName)
+	return len(Check(name)) == 0
+}
 
-	if cleanName == "" {
-		return false
+// Validate returns a *NameError if name is not a valid path
+func Validate(name string) error {
+	if violations := Check(name); len(violations) > 0 {
+		return &NameError{Name: name, Violations: violations}
 	}
+	return nil
+}
 
-	// Check for dangerous path patterns
-	if cleanName == "." || cleanName == ".." {
-		return false
+// Check returns the rules name breaks, in order of position, or nil if it
+// is a safe and valid path. A trailing slash and surrounding spaces are
+// ignored; positions count from the start of name.
+func Check(name string) []Violation {
+	// Removing the ending slash for verification
+	trimmed := strings.TrimSuffix(name, "/")
+	cleanName := strings.TrimSpace(trimmed)
+	if cleanName == "" {
+		return []Violation{{Kind: Blank}}
 	}
+	lead := utf8.RuneCountInString(trimmed) - utf8.RuneCountInString(strings.TrimLeftFunc(trimmed, unicode.IsSpace))
 
-	// Check for path components with ".." or "."
-	parts := strings.Split(cleanName, "/")
-	for _, part := range parts {
-		if part == ".." || part == "." {
-			return false
-		}
-	}
+	var violations []Violation
 
-	// Check for prohibited characters
-	forbiddenChars := []string{"//", "\\", ":", "*", "?", "\"", "<", ">", "|"}
-	for _, char := range forbiddenChars {
-		if strings.Contains(cleanName, char) {
-			return false
+	// Check for path components with ".." or "." and prohibited characters
+	position, segmentStart := lead, lead+1
+	prev := rune(0)
+	for i, r := range cleanName {
+		position++
+		switch {
+		case r == '/' && prev == '/':
+			if i < 2 || cleanName[i-2] != '/' {
+				violations = append(violations, Violation{Kind: ForbiddenChar, Value: "//", Position: position - 1})
+			}
+		case strings.ContainsRune(forbiddenChars, r):
+			violations = append(violations, Violation{Kind: ForbiddenChar, Value: string(r), Position: position})
 		}
+		if r == '/' {
+			violations = appendDotSegment(violations, cleanName, i, segmentStart)
+			segmentStart = position + 1
+		}
+		prev = r
 	}
+	violations = appendDotSegment(violations, cleanName, len(cleanName), segmentStart)
 
 	// Checking for reserved Windows names
-	forbiddenNames := []string{"CON", "PRN", "AUX", "NUL", "COM1", "COM2", "COM3", "COM4", "COM5",
-		"COM6", "COM7", "COM8", "COM9", "LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9"}
-
 	base := filepath.Base(cleanName)
-	for _, forbidden := range forbiddenNames {
-		if strings.EqualFold(base, forbidden) {
-			return false
+	for _, reserved := range reservedNames {
+		if strings.EqualFold(base, reserved) {
+			violations = append(violations, Violation{Kind: ReservedName, Value: base})
 		}
 	}
 
-	// Checking the length of the name (maximum 255 characters)
-	if utf8.RuneCountInString(cleanName) > 255 {
-		return false
+	// Checking the length of the name
+	if length := utf8.RuneCountInString(cleanName); length > MaxNameLength {
+		violations = append(violations, Violation{Kind: TooLong, Length: length})
 	}
 
-	// Check that the name does not consist only of spaces
-	if strings.TrimSpace(cleanName) == "" {
-		return false
-	}
+	return violations
+}
 
-	return true
+// appendDotSegment adds a violation if the segment of name ending at byte
+// end, which starts at the given character position, is "." or ".."
+func appendDotSegment(violations []Violation, name string, end, position int) []Violation {
+	start := strings.LastIndex(name[:end], "/") + 1
+	if segment := name[start:end]; segment == "." || segment == ".." {
+		violations = append(violations, Violation{Kind: DotSegment, Value: segment, Position: position})
+	}
+	return violations
 }
@@ -1,6 +1,8 @@
 package validator
 
 import (
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -167,6 +169,47 @@ func TestIsValidPath_RealWorldExamples(t *testing.T) {
 	}
 }
 
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []Violation
+	}{
+		{"file.txt", nil},
+		{"bad:name", []Violation{{Kind: ForbiddenChar, Value: ":", Position: 4}}},
+		{"  a*b?.txt/", []Violation{{Kind: ForbiddenChar, Value: "*", Position: 4}, {Kind: ForbiddenChar, Value: "?", Position: 6}}},
+		{"a///b", []Violation{{Kind: ForbiddenChar, Value: "//", Position: 2}}},
+		{"../up", []Violation{{Kind: DotSegment, Value: "..", Position: 1}}},
+		{"a/./b", []Violation{{Kind: DotSegment, Value: ".", Position: 3}}},
+		{"src/..", []Violation{{Kind: DotSegment, Value: "..", Position: 5}}},
+		{"docs/nul", []Violation{{Kind: ReservedName, Value: "nul"}}},
+		{"   ", []Violation{{Kind: Blank}}},
+		{strings.Repeat("é", 256), []Violation{{Kind: TooLong, Length: 256}}},
+	}
+
+	for _, tt := range tests {
+		got := Check(tt.input)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Check(%q) = %+v, expected %+v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("main.go"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	err := Validate("a:b/CON")
+	var nameErr *NameError
+	if !errors.As(err, &nameErr) || len(nameErr.Violations) != 2 {
+		t.Fatalf("Expected a NameError with 2 violations, got %v", err)
+	}
+	expected := "invalid name: forbidden character ':' at position 2, reserved Windows name 'CON'"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+}
+
 // Benchmark test for performance
 func BenchmarkIsValidPath(b *testing.B) {
 	testCases := []string{
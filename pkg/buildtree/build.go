@@ -0,0 +1,200 @@
+package buildtree
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+
+	"github.com/neomen/buildtree/internal/builder"
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/internal/skeleton"
+)
+
+// DefaultPlaceholder is the file that keeps empty directories in git
+const DefaultPlaceholder = ".gitkeep"
+
+// ContentProvider supplies the initial content of files the spec leaves empty
+type ContentProvider interface {
+	// Content returns the content and permissions for the file at path,
+	// or "" to leave it empty
+	Content(path string) (string, fs.FileMode, error)
+}
+
+// EventKind is the outcome of an Event
+type EventKind string
+
+// Event kinds
+const (
+	Created        EventKind = "created"
+	Existed        EventKind = "existed" // Already on disk; files are rewritten
+	SkippedInvalid EventKind = "skipped-invalid"
+	SkippedDepth   EventKind = "skipped-depth" // The subtree below is skipped too
+	Failed         EventKind = "error"
+	Placeholder    EventKind = "placeholder" // Added for BuildOptions.Placeholder
+)
+
+// eventKinds lists all event kinds in the order they are reported
+var eventKinds = []EventKind{Created, Existed, SkippedInvalid, SkippedDepth, Failed, Placeholder}
+
+// Event describes what happened to one path during a build
+type Event struct {
+	Kind   EventKind `json:"outcome"`
+	Path   string    `json:"path"` // Slash-separated, as built
+	IsDir  bool      `json:"-"`
+	Type   string    `json:"type"`             // "dir" or "file"
+	Reason string    `json:"reason,omitempty"` // Why a path was skipped or failed
+	// Violations are the rules an invalid name breaks, for SkippedInvalid
+	Violations []Violation `json:"violations,omitempty"`
+	Err        error       `json:"-"` // Set for Failed events
+	Node       *Node       `json:"-"`
+}
+
+// Observer receives build events as they happen
+type Observer func(Event)
+
+// Report lists the outcome of every path in a build. Placeholders are
+// listed apart from the files of the spec so that they can be removed
+// later. Reports of streamed builds only hold the counts.
+type Report struct {
+	Root         string   // Name of the root directory
+	Dirs         []string // Directories created or already present
+	Files        []string // Files written
+	Placeholders []string
+	Events       []Event
+
+	counts map[EventKind]int
+}
+
+// Counts returns the number of events of each kind
+func (r *Report) Counts() map[EventKind]int {
+	counts := make(map[EventKind]int, len(eventKinds))
+	for _, kind := range eventKinds {
+		counts[kind] = r.counts[kind]
+	}
+	return counts
+}
+
+// BuildOptions control how a tree is built. The zero value builds
+// sequentially, without a depth limit, placeholders or skeletons.
+type BuildOptions struct {
+	MaxDepth int             // Maximum nesting depth (0 = no limit)
+	Jobs     int             // Paths created in parallel (0 or 1 = sequential)
+	Content  ContentProvider // Fills files without content, if set
+	// Placeholder is a file name such as ".gitkeep" created in every
+	// directory left empty by the build ("" = none)
+	Placeholder string
+	Observer    Observer // Receives an event for every path, if set
+	// NoRoot builds the children of the root in the current directory
+	// instead of a directory named after the root. It is implied for a
+	// CurrentDir root.
+	NoRoot bool
+	// Dir is the directory the tree is built in, created if missing
+	// ("" = the current directory). Paths in the report start with it.
+	Dir string
+}
+
+// Build creates the tree in opts.Dir or the current directory. Existing files are
+// overwritten by files of the tree. When ctx is done the build stops
+// before the next path and returns ctx.Err(); paths already created are
+// kept. A path that cannot be created is returned as a *BuildError. The
+// report is returned even when the build fails.
+func Build(ctx context.Context, root *Node, opts BuildOptions) (*Report, error) {
+	report, err := builder.BuildContext(ctx, root, opts.builder())
+	return newReport(report, err)
+}
+
+// BuildStream creates the tree read from a tree diagram line by line, so
+// that memory use depends on the depth of the tree rather than its size.
+// Includes, blocks and variables are not processed, Jobs is ignored, and
+// the report holds counts only.
+func BuildStream(ctx context.Context, r io.Reader, opts BuildOptions) (*Report, error) {
+	report, err := builder.BuildStream(ctx, parser.NewScanner(r), opts.builder())
+	return newReport(report, err)
+}
+
+// Rollback removes the paths a build reported as created, newest first,
+// for example after Build was canceled. Paths that existed before are
+// kept, and so are directories that hold anything else. It returns the
+// number of paths removed.
+func Rollback(events []Event) (int, error) {
+	built := make([]builder.Event, len(events))
+	for i, event := range events {
+		built[i] = builder.Event{Kind: builder.EventKind(event.Kind), Path: event.Path, IsDir: event.IsDir}
+	}
+	return builder.Rollback(built)
+}
+
+// Skeletons returns a ContentProvider that fills empty files from
+// per-extension skeletons, searching dirs before the user's skeleton
+// directory and the built-in defaults
+func Skeletons(dirs ...string) (ContentProvider, error) {
+	defaultDir, err := skeleton.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return &skeleton.Provider{Dirs: append(append([]string{}, dirs...), defaultDir)}, nil
+}
+
+func (o BuildOptions) builder() builder.Options {
+	opts := builder.Options{
+		MaxDepth:    o.MaxDepth,
+		Jobs:        o.Jobs,
+		Placeholder: o.Placeholder,
+		NoRoot:      o.NoRoot,
+		Dir:         o.Dir,
+	}
+	if o.Content != nil {
+		opts.Content = o.Content
+	}
+	if observe := o.Observer; observe != nil {
+		opts.Observer = func(event builder.Event) {
+			observe(newEvent(event))
+		}
+	}
+	return opts
+}
+
+// newReport converts the builder's report and wraps the failure of a path
+// in a BuildError
+func newReport(built *builder.Report, err error) (*Report, error) {
+	if built == nil {
+		return nil, err
+	}
+	report := &Report{
+		Root:         built.Root,
+		Dirs:         built.Dirs,
+		Files:        built.Files,
+		Placeholders: built.Placeholders,
+		Events:       make([]Event, len(built.Events)),
+		counts:       make(map[EventKind]int, len(eventKinds)),
+	}
+	for i, event := range built.Events {
+		report.Events[i] = newEvent(event)
+	}
+	for kind, n := range built.Counts() {
+		report.counts[EventKind(kind)] = n
+	}
+
+	if err != nil {
+		for _, event := range report.Events {
+			if event.Kind == Failed && errors.Is(event.Err, err) {
+				return report, &BuildError{Path: event.Path, Err: err}
+			}
+		}
+	}
+	return report, err
+}
+
+func newEvent(event builder.Event) Event {
+	return Event{
+		Kind:       EventKind(event.Kind),
+		Path:       event.Path,
+		IsDir:      event.IsDir,
+		Type:       event.Type,
+		Reason:     event.Reason,
+		Violations: newViolations(event.Violations),
+		Err:        event.Err,
+		Node:       event.Node,
+	}
+}
@@ -0,0 +1,165 @@
+package buildtree
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	root, err := Parse(context.Background(), "app/\n├── src/\n│   └── main.go\n├── docs/\n└── bad:name", ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var skipped []string
+	report, err := Build(context.Background(), root, BuildOptions{
+		Placeholder: DefaultPlaceholder,
+		Observer: func(event Event) {
+			if event.Kind == SkippedInvalid {
+				skipped = append(skipped, event.Path)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"app/src/main.go", "app/docs/.gitkeep"} {
+		if _, err := os.Stat(filepath.FromSlash(path)); err != nil {
+			t.Errorf("Expected %s to exist: %v", path, err)
+		}
+	}
+	if counts := report.Counts(); counts[Created] != 4 || counts[Placeholder] != 1 {
+		t.Errorf("Unexpected counts %v", counts)
+	}
+	if len(skipped) != 1 || skipped[0] != "app/bad:name" {
+		t.Errorf("Expected the invalid name to be skipped, got %v", skipped)
+	}
+}
+
+func TestBuild_Errors(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if _, err := Build(context.Background(), &Node{Name: "bad:name", IsDir: true}, BuildOptions{}); !errors.Is(err, ErrInvalidRoot) {
+		t.Errorf("Expected ErrInvalidRoot, got %v", err)
+	}
+	if _, err := Build(context.Background(), &Node{Name: "app", IsDir: true}, BuildOptions{Placeholder: "a:b"}); !errors.Is(err, ErrInvalidPlaceholder) {
+		t.Errorf("Expected ErrInvalidPlaceholder, got %v", err)
+	}
+
+	// A file in the way of a directory
+	writeFile(t, "app", "")
+	_, err := Build(context.Background(), &Node{Name: "app", IsDir: true}, BuildOptions{})
+	var buildErr *BuildError
+	if !errors.As(err, &buildErr) || buildErr.Path != "app" {
+		t.Errorf("Expected a BuildError for app, got %v", err)
+	}
+}
+
+func TestBuild_Canceled(t *testing.T) {
+	t.Chdir(t.TempDir())
+	root := &Node{Name: "app", IsDir: true, Children: []*Node{{Name: "a"}, {Name: "b"}}}
+
+	// Stop once the root exists
+	ctx, cancel := context.WithCancel(context.Background())
+	report, err := Build(ctx, root, BuildOptions{Observer: func(Event) { cancel() }})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join("app", "a")); statErr == nil {
+		t.Error("Expected the build to stop after the root")
+	}
+	if counts := report.Counts(); counts[Created] != 1 {
+		t.Errorf("Unexpected counts %v", counts)
+	}
+
+	// Nothing is created in parallel once the context is done
+	if _, err := Build(ctx, &Node{Name: "other", IsDir: true}, BuildOptions{Jobs: 4}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled in parallel, got %v", err)
+	}
+	if _, statErr := os.Stat("other"); statErr == nil {
+		t.Error("Expected no paths after cancellation")
+	}
+}
+
+func TestBuildStream(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	report, err := BuildStream(context.Background(), strings.NewReader("app/\n└── src/\n    └── main.go\n"), BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Root != "app" || report.Counts()[Created] != 3 {
+		t.Errorf("Unexpected report %+v", report.Counts())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := BuildStream(ctx, strings.NewReader("app/"), BuildOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestBuild_Dir(t *testing.T) {
+	t.Chdir(t.TempDir())
+	spec := "app/\n├── src/\n│   └── main.go\n└── docs/\n"
+
+	tests := []struct {
+		name  string
+		opts  BuildOptions
+		paths []string
+	}{
+		{"sequential", BuildOptions{Dir: "out/seq"}, []string{"out/seq/app/src/main.go", "out/seq/app/docs"}},
+		{"parallel", BuildOptions{Dir: "out/par", Jobs: 4}, []string{"out/par/app/src/main.go", "out/par/app/docs"}},
+		{"no root", BuildOptions{Dir: "out/flat", NoRoot: true, Jobs: 4}, []string{"out/flat/src/main.go", "out/flat/docs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := Parse(context.Background(), spec, ParseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			report, err := Build(context.Background(), root, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, path := range tt.paths {
+				if _, err := os.Stat(filepath.FromSlash(path)); err != nil {
+					t.Errorf("Expected %s to exist: %v", path, err)
+				}
+			}
+			if last := report.Events[len(report.Events)-1].Path; last != tt.paths[1] {
+				t.Errorf("Expected report paths under %s, got %s", tt.opts.Dir, last)
+			}
+		})
+	}
+
+	if _, err := BuildStream(context.Background(), strings.NewReader(spec), BuildOptions{Dir: "out/stream"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join("out", "stream", "app", "src", "main.go")); err != nil {
+		t.Errorf("Expected the streamed tree under out/stream: %v", err)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	root := &Node{Name: "app", IsDir: true, Children: []*Node{{Name: "a"}, {Name: "b"}}}
+	report, err := Build(context.Background(), root, BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := Rollback(report.Events); err != nil || removed != 3 {
+		t.Errorf("Expected 3 paths removed, got %d (%v)", removed, err)
+	}
+	if _, err := os.Stat("app"); !os.IsNotExist(err) {
+		t.Errorf("Expected app to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+// Package buildtree parses tree diagrams and other structure specs and
+// creates the files and directories they describe. It is the library
+// behind the buildtree command.
+//
+// The API of this package follows semantic versioning: within a major
+// version, exported names are only added, never removed or changed in an
+// incompatible way. Types are declared in this package, or in package
+// tree for the tree model, such as Node; the aliases of package tree are
+// kept compatible like the rest of the API. Packages under internal/
+// carry no promise and may change at any time.
+package buildtree
+
+import (
+	"context"
+	"os"
+
+	"github.com/neomen/buildtree/internal/expand"
+	"github.com/neomen/buildtree/internal/format"
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// Node is a file or directory of a parsed tree
+type Node = tree.Node
+
+// SkipSubtree is returned by the pre-order function of Node.Walk to skip
+// the node's descendants
+var SkipSubtree = tree.SkipSubtree
+
+// CurrentDir names the root of a spec without a single top-level
+// directory, such as a diagram starting with "." or listing several
+// roots. Its children are built in the output directory.
+const CurrentDir = tree.CurrentDir
+
+// Format identifies a textual representation of a tree
+type Format string
+
+// Supported formats
+const (
+	FormatTree     Format = "tree"     // Unicode tree diagram
+	FormatASCII    Format = "ascii"    // ASCII tree diagram
+	FormatIndent   Format = "indent"   // Indented list without glyphs
+	FormatPaths    Format = "paths"    // One slash-separated path per line
+	FormatJSON     Format = "json"     // Nested JSON objects
+	FormatYAML     Format = "yaml"     // Nested YAML mappings
+	FormatMarkdown Format = "markdown" // Markdown nested bullet list
+)
+
+// Formats lists all supported formats
+var Formats = []Format{FormatTree, FormatASCII, FormatIndent, FormatPaths, FormatJSON, FormatYAML, FormatMarkdown}
+
+// LookupFormat returns the format with the given name, such as "yaml" or
+// its alias "yml"
+func LookupFormat(name string) (Format, error) {
+	f, err := format.Lookup(name)
+	return Format(f), err
+}
+
+// DetectFormat guesses the format of a file from its extension,
+// defaulting to FormatTree
+func DetectFormat(path string) Format {
+	return Format(format.Detect(path))
+}
+
+// ParseOptions control how a spec is read
+type ParseOptions struct {
+	// Format of the input (default FormatTree, which accepts any glyph set)
+	Format Format
+	// File is the path the spec was read from. Relative @include paths
+	// resolve from its directory, or from the current directory if empty.
+	File string
+	// Values are substituted for {{ .Name }} placeholders and decide
+	// @if and @each blocks
+	Values map[string]any
+	// Raw keeps @include lines, blocks and placeholders as written
+	Raw bool
+}
+
+// Parse reads a spec, splices in its includes and expands its template
+// variables and blocks, unless opts.Raw is set. Errors are of the types
+// declared in this package where one applies.
+func Parse(ctx context.Context, input string, opts ParseOptions) (*Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f := opts.Format
+	if f == "" {
+		f = FormatTree
+	}
+	root, err := format.Parse(format.Format(f), input)
+	if err != nil || opts.Raw {
+		return root, err
+	}
+	return Resolve(ctx, root, opts)
+}
+
+// ResolveIncludes splices the includes of a tree parsed with opts.Raw
+// into it, leaving its variables and blocks as written. Relative paths
+// resolve from the directory of file, or from the current directory if
+// file is empty.
+func ResolveIncludes(root *Node, file string) error {
+	return apiError(parser.ResolveIncludes(root, file))
+}
+
+// Resolve splices the includes of a tree parsed with opts.Raw into it and
+// returns a copy with its variables and blocks expanded. opts.Format and
+// opts.Raw are ignored.
+func Resolve(ctx context.Context, root *Node, opts ParseOptions) (*Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := ResolveIncludes(root, opts.File); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	expanded, err := expand.Expand(root, opts.Values)
+	return expanded, apiError(err)
+}
+
+// ParseFile reads the spec at path, detecting its format from the
+// extension unless opts.Format is set
+func ParseFile(ctx context.Context, path string, opts ParseOptions) (*Node, error) {
+	input, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Format == "" {
+		opts.Format = DetectFormat(path)
+	}
+	opts.File = path
+	return Parse(ctx, string(input), opts)
+}
+
+// Variables returns the names of the template variables used in a tree,
+// excluding those bound by @each blocks
+func Variables(root *Node) ([]string, error) {
+	names, err := expand.Variables(root)
+	return names, apiError(err)
+}
@@ -0,0 +1,134 @@
+package buildtree
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := "{{ .Name }}/\n├── @if docker\n│   └── Dockerfile\n└── main.go"
+
+	root, err := Parse(context.Background(), input, ParseOptions{Values: map[string]any{"Name": "app", "docker": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := paths(root), []string{"app", "app/Dockerfile", "app/main.go"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	raw, err := Parse(context.Background(), input, ParseOptions{Raw: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.Name != "{{ .Name }}" || raw.Children[0].Directive != "if" {
+		t.Errorf("Raw parse should keep placeholders and directives, got %+v", raw)
+	}
+}
+
+func TestParse_Formats(t *testing.T) {
+	root, err := Parse(context.Background(), "app/src/main.go\napp/README.md\n", ParseOptions{Format: FormatPaths})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := paths(root), []string{"app", "app/src", "app/src/main.go", "app/README.md"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestLookupFormat(t *testing.T) {
+	if f, err := LookupFormat("yml"); err != nil || f != FormatYAML {
+		t.Errorf("LookupFormat(yml) = %v, %v", f, err)
+	}
+	if _, err := LookupFormat("xml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+	if f := DetectFormat("spec.md"); f != FormatMarkdown {
+		t.Errorf("DetectFormat(spec.md) = %v", f)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "shared.tree"), "shared/\n└── ci.yml\n")
+	writeFile(t, filepath.Join(dir, "spec.json"), `{"name": "app", "type": "dir", "children": [{"name": "go.mod"}]}`)
+	writeFile(t, filepath.Join(dir, "spec.tree"), "app/\n├── @include shared.tree\n└── go.mod\n")
+
+	root, err := ParseFile(context.Background(), filepath.Join(dir, "spec.tree"), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := paths(root), []string{"app", "app/ci.yml", "app/go.mod"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// Raw keeps the directive until the includes are resolved
+	root, err = ParseFile(context.Background(), filepath.Join(dir, "spec.tree"), ParseOptions{Raw: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ResolveIncludes(root, filepath.Join(dir, "spec.tree")); err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := paths(root), []string{"app", "app/ci.yml", "app/go.mod"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v after ResolveIncludes, got %v", expected, got)
+	}
+
+	root, err = ParseFile(context.Background(), filepath.Join(dir, "spec.json"), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := paths(root), []string{"app", "app/go.mod"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := Parse(ctx, "", ParseOptions{}); !errors.Is(err, ErrEmptyInput) {
+		t.Errorf("Expected ErrEmptyInput, got %v", err)
+	}
+
+	var undefined *UndefinedError
+	if _, err := Parse(ctx, "{{ .Missing }}/", ParseOptions{}); !errors.As(err, &undefined) || undefined.Names[0] != "Missing" {
+		t.Errorf("Expected an UndefinedError for Missing, got %v", err)
+	}
+
+	var include *IncludeError
+	if _, err := Parse(ctx, "app/\n└── @include missing.tree", ParseOptions{File: filepath.Join(t.TempDir(), "spec.tree")}); !errors.As(err, &include) || include.Line != 2 {
+		t.Errorf("Expected an IncludeError on line 2, got %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := Parse(canceled, "app/", ParseOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// paths lists the slash-separated paths of the tree in pre-order
+func paths(root *Node) []string {
+	var list, stack []string
+	root.Walk(func(node *Node, depth int) error {
+		stack = append(stack[:depth], node.Name)
+		path := node.Name
+		if depth > 0 {
+			path = stack[depth-1] + "/" + node.Name
+			stack[depth] = path
+		}
+		list = append(list, path)
+		return nil
+	}, nil)
+	return list
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,92 @@
+package buildtree
+
+import (
+	"github.com/neomen/buildtree/internal/builder"
+	"github.com/neomen/buildtree/internal/expand"
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// Errors returned by Parse, ParseFile and Build, for use with errors.Is
+var (
+	ErrEmptyInput         = parser.ErrEmptyInput
+	ErrIncludeCycle       = parser.ErrIncludeCycle
+	ErrInvalidRoot        = builder.ErrInvalidRoot
+	ErrInvalidPlaceholder = builder.ErrInvalidPlaceholder
+)
+
+// Errors returned by the editing methods of Node and by Merge
+var (
+	ErrExists          = tree.ErrExists
+	ErrNotDir          = tree.ErrNotDir
+	ErrInvalidName     = tree.ErrInvalidName
+	ErrCycle           = tree.ErrCycle
+	ErrTypeConflict    = tree.ErrTypeConflict
+	ErrContentConflict = tree.ErrContentConflict
+)
+
+// IncludeError reports an @include line that could not be resolved
+type IncludeError struct {
+	File string // Spec containing the directive ("" for inline input)
+	Line int
+	Path string // Argument of the directive
+	Err  error
+}
+
+func (e *IncludeError) Error() string {
+	return (*parser.IncludeError)(e).Error()
+}
+
+func (e *IncludeError) Unwrap() error {
+	return e.Err
+}
+
+// UndefinedError lists the template variables used without a value
+type UndefinedError struct {
+	Names []string
+}
+
+func (e *UndefinedError) Error() string {
+	return (*expand.UndefinedError)(e).Error()
+}
+
+// InvalidNameError lists the names that are not valid paths after
+// variables are substituted
+type InvalidNameError struct {
+	Names []string
+}
+
+func (e *InvalidNameError) Error() string {
+	return (*expand.InvalidNameError)(e).Error()
+}
+
+// BuildError reports a path that could not be created. Its message is
+// that of Err, which already names the path.
+type BuildError struct {
+	Path string // Slash-separated, as in the report
+	Err  error
+}
+
+func (e *BuildError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+// apiError converts errors of the internal packages to the types declared
+// here
+func apiError(err error) error {
+	switch e := err.(type) {
+	case *parser.IncludeError:
+		converted := IncludeError(*e)
+		converted.Err = apiError(e.Err)
+		return &converted
+	case *expand.UndefinedError:
+		return (*UndefinedError)(e)
+	case *expand.InvalidNameError:
+		return (*InvalidNameError)(e)
+	}
+	return err
+}
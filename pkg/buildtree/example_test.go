@@ -0,0 +1,33 @@
+package buildtree_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/neomen/buildtree/pkg/buildtree"
+)
+
+func ExampleParse() {
+	spec := `{{ .Name }}/
+├── @each svc in services
+│   └── {{ .svc }}/
+│       └── main.go
+└── go.mod`
+
+	root, err := buildtree.Parse(context.Background(), spec, buildtree.ParseOptions{
+		Values: map[string]any{"Name": "app", "services": []any{"api", "worker"}},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	buildtree.Render(os.Stdout, root, buildtree.RenderOptions{})
+	// Output:
+	// app/
+	// ├── api/
+	// │   └── main.go
+	// ├── worker/
+	// │   └── main.go
+	// └── go.mod
+}
@@ -0,0 +1,44 @@
+package buildtree
+
+import (
+	"errors"
+
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+// MergePolicy decides a conflict between two nodes at the same path
+type MergePolicy = tree.MergePolicy
+
+// Merge policies
+const (
+	MergeError = tree.MergeError // Fail with a *ConflictError
+	MergeFirst = tree.MergeFirst // Keep the node from the earlier tree
+	MergeLast  = tree.MergeLast  // Take the node from the later tree
+)
+
+// MergePolicies lists all merge policies
+var MergePolicies = tree.MergePolicies
+
+// MergeOptions set how Merge resolves conflicts. The zero value fails on
+// type conflicts and lets later contents win.
+type MergeOptions = tree.MergeOptions
+
+// ConflictError reports a conflict under the MergeError policy
+type ConflictError = tree.ConflictError
+
+// Merge returns a new tree holding the trees merged in order: a base
+// layout first, then its overlays. Nodes are matched by path below the
+// roots, and the result takes the first root's name. The trees are left
+// unchanged.
+func Merge(opts MergeOptions, trees ...*Node) (*Node, error) {
+	if len(trees) == 0 {
+		return nil, errors.New("nothing to merge")
+	}
+	merged := trees[0].Clone()
+	for _, tree := range trees[1:] {
+		if err := merged.MergeWith(tree, opts); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
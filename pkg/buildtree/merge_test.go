@@ -0,0 +1,45 @@
+package buildtree
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	ctx := context.Background()
+	base, err := Parse(ctx, "app/\n├── src/\n│   └── main.go\n└── go.mod", ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlay, err := Parse(ctx, "team/\n├── src/\n│   └── team.go\n└── OWNERS", ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := Merge(MergeOptions{}, base, overlay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"app", "app/src", "app/src/main.go", "app/src/team.go", "app/go.mod", "app/OWNERS"}
+	if got := paths(merged); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if base.Find("src/team.go") != nil {
+		t.Error("Merge changed its input")
+	}
+
+	conflicting, err := Parse(ctx, "app/\n└── go.mod/", ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conflict *ConflictError
+	if _, err := Merge(MergeOptions{}, base, conflicting); !errors.As(err, &conflict) || conflict.Path != "app/go.mod" {
+		t.Errorf("Expected a ConflictError for app/go.mod, got %v", err)
+	}
+
+	if _, err := Merge(MergeOptions{}); err == nil {
+		t.Error("Expected an error without trees")
+	}
+}
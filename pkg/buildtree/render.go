@@ -0,0 +1,47 @@
+package buildtree
+
+import (
+	"io"
+
+	"github.com/neomen/buildtree/internal/format"
+	"github.com/neomen/buildtree/internal/render"
+)
+
+// Style selects the glyph set of a tree diagram
+type Style string
+
+// Diagram styles
+const (
+	StyleUnicode  Style = "unicode"  // ├──, └──, │
+	StyleASCII    Style = "ascii"    // |--, '--, |
+	StyleIndented Style = "indented" // Plain indentation without glyphs
+)
+
+// DefaultIndent is the number of columns per level when
+// RenderOptions.Indent is 0
+const DefaultIndent = 4
+
+// RenderOptions control how a tree is written. The zero value writes a
+// Unicode tree diagram in the canonical style.
+type RenderOptions struct {
+	// Format to write (default FormatTree). Style, Indent, Sort and
+	// Comments only apply to FormatTree.
+	Format   Format
+	Style    Style // Glyph set (default unicode)
+	Indent   int   // Columns per level, at least 2 (default 4)
+	Sort     bool  // Order directories first, then by name
+	Comments bool  // Keep "#" comments attached to nodes
+}
+
+// Render writes the tree in the syntax Parse reads back
+func Render(w io.Writer, root *Node, opts RenderOptions) error {
+	if opts.Format != "" && opts.Format != FormatTree {
+		return format.Render(format.Format(opts.Format), w, root)
+	}
+	return render.Tree(w, root, render.Options{
+		Style:    render.Style(opts.Style),
+		Indent:   opts.Indent,
+		Sort:     opts.Sort,
+		Comments: opts.Comments,
+	})
+}
@@ -0,0 +1,34 @@
+package buildtree
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	root, err := Parse(context.Background(), "app/\n├── main.go  # entry point\n└── docs/", ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		opts     RenderOptions
+		expected string
+	}{
+		{RenderOptions{}, "app/\n├── main.go\n└── docs/\n"},
+		{RenderOptions{Comments: true, Sort: true}, "app/\n├── docs/\n└── main.go # entry point\n"},
+		{RenderOptions{Style: StyleASCII, Indent: 2}, "app/\n| main.go\n' docs/\n"},
+		{RenderOptions{Format: FormatPaths}, "app/\napp/main.go\napp/docs/\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Render(&buf, root, tt.opts); err != nil {
+			t.Fatalf("%+v: %v", tt.opts, err)
+		}
+		if buf.String() != tt.expected {
+			t.Errorf("%+v: expected\n%s\ngot\n%s", tt.opts, tt.expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package buildtree
+
+import "github.com/neomen/buildtree/internal/validator"
+
+// Violation is one rule a name breaks
+type Violation struct {
+	Kind     ViolationKind `json:"kind"`
+	Value    string        `json:"value,omitempty"`    // Offending character, segment or reserved name
+	Position int           `json:"position,omitempty"` // 1-based character position of Value in the name
+	Length   int           `json:"length,omitempty"`   // Length of the name, for TooLong
+}
+
+func (v Violation) String() string {
+	return v.validator().String()
+}
+
+func (v Violation) validator() validator.Violation {
+	return validator.Violation{Kind: validator.ViolationKind(v.Kind), Value: v.Value, Position: v.Position, Length: v.Length}
+}
+
+// ViolationKind identifies the rule of a Violation
+type ViolationKind string
+
+// Violation kinds
+const (
+	ForbiddenChar ViolationKind = "forbidden-char" // A character or "//" not allowed in names
+	ReservedName  ViolationKind = "reserved-name"  // A device name reserved on Windows
+	TooLong       ViolationKind = "too-long"       // Longer than 255 characters
+	DotSegment    ViolationKind = "dot-segment"    // A "." or ".." path segment
+	Blank         ViolationKind = "blank"          // Empty or whitespace-only
+)
+
+// NameError reports the violations of a name that is not a valid path.
+// Skipped paths carry the same violations in their build Event.
+type NameError struct {
+	Name       string
+	Violations []Violation
+}
+
+func (e *NameError) Error() string {
+	violations := make([]validator.Violation, len(e.Violations))
+	for i, v := range e.Violations {
+		violations[i] = v.validator()
+	}
+	return (&validator.NameError{Name: e.Name, Violations: violations}).Error()
+}
+
+// ValidateName returns a *NameError if name is not a valid path
+func ValidateName(name string) error {
+	if violations := validator.Check(name); len(violations) > 0 {
+		return &NameError{Name: name, Violations: newViolations(violations)}
+	}
+	return nil
+}
+
+// CollisionError is a name that clashes with an earlier sibling on some
+// file systems
+type CollisionError struct {
+	Path  string // Slash-separated path of the later node, from the root
+	Other string // Name of the earlier sibling it clashes with
+	Line  int    // Line of the later node, if known
+	File  string // Spec file of the later node, if known
+	Cause error  // ErrDuplicate, ErrCaseCollision or ErrNormalizationCollision
+}
+
+func (e *CollisionError) Error() string {
+	return (*validator.CollisionError)(e).Error()
+}
+
+func (e *CollisionError) Unwrap() error {
+	return e.Cause
+}
+
+// Causes of a CollisionError, for use with errors.Is
+var (
+	ErrDuplicate              = validator.ErrDuplicate
+	ErrCaseCollision          = validator.ErrCaseCollision
+	ErrNormalizationCollision = validator.ErrNormalizationCollision
+)
+
+// Collisions returns the names in the tree that clash with an earlier
+// sibling: exact duplicates, names that differ only in Unicode
+// normalization (NFC/NFD), and names that differ only in case. Such trees
+// build on Linux but not in a checkout on macOS or Windows.
+func Collisions(root *Node) []*CollisionError {
+	var collisions []*CollisionError
+	for _, c := range validator.Collisions(root) {
+		collisions = append(collisions, (*CollisionError)(c))
+	}
+	return collisions
+}
+
+func newViolations(violations []validator.Violation) []Violation {
+	if violations == nil {
+		return nil
+	}
+	converted := make([]Violation, len(violations))
+	for i, v := range violations {
+		converted[i] = Violation{Kind: ViolationKind(v.Kind), Value: v.Value, Position: v.Position, Length: v.Length}
+	}
+	return converted
+}
@@ -0,0 +1,37 @@
+package buildtree
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCollisions(t *testing.T) {
+	root, err := Parse(context.Background(), "app/\n├── README.md\n├── readme.md\n└── go.mod", ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collisions := Collisions(root)
+	if len(collisions) != 1 {
+		t.Fatalf("Expected 1 collision, got %v", collisions)
+	}
+	if c := collisions[0]; c.Path != "app/readme.md" || c.Other != "README.md" || !errors.Is(c, ErrCaseCollision) {
+		t.Errorf("Unexpected collision %v", c)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	if err := ValidateName("main.go"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	var nameErr *NameError
+	if err := ValidateName("bad:name"); !errors.As(err, &nameErr) {
+		t.Fatalf("Expected a NameError, got %v", err)
+	}
+	expected := Violation{Kind: ForbiddenChar, Value: ":", Position: 4}
+	if len(nameErr.Violations) != 1 || nameErr.Violations[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, nameErr.Violations)
+	}
+}
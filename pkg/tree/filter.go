@@ -0,0 +1,105 @@
+package tree
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/neomen/buildtree/internal/glob"
+)
+
+// Filter removes the nodes below n that are not selected. Patterns match
+// slash-separated paths relative to n, segment by segment as with
+// path.Match, and a "**" segment spans any number of directories.
+//
+// With only patterns, a node is kept if its path matches one of them, if
+// it is inside a matching directory, or if it is a directory leading to a
+// match. Nodes matching an exclude pattern are then removed with
+// everything below them.
+func (n *Node) Filter(only, exclude []string) error {
+	for _, pattern := range append(append([]string{}, only...), exclude...) {
+		if err := checkPattern(pattern); err != nil {
+			return err
+		}
+	}
+
+	if len(only) > 0 {
+		kept := map[*Node]bool{}
+		var remove []*Node
+		n.walkPaths(func(node *Node, rel string) error {
+			if rel != "" && matchAny(only, rel) {
+				// Keep the node and the directories leading to it
+				for p := node; p != nil && !kept[p]; p = p.parent {
+					kept[p] = true
+				}
+				return SkipSubtree
+			}
+			return nil
+		}, func(node *Node) {
+			if node != n && !kept[node] {
+				remove = append(remove, node)
+			}
+		})
+		for _, node := range remove {
+			node.Remove()
+		}
+	}
+
+	if len(exclude) > 0 {
+		var remove []*Node
+		n.walkPaths(func(node *Node, rel string) error {
+			if rel != "" && matchAny(exclude, rel) {
+				remove = append(remove, node)
+				return SkipSubtree
+			}
+			return nil
+		}, nil)
+		for _, node := range remove {
+			node.Remove()
+		}
+	}
+	return nil
+}
+
+// walkPaths walks the tree below n, passing pre the path of each node
+// relative to n ("" for n itself)
+func (n *Node) walkPaths(pre func(node *Node, rel string) error, post func(node *Node)) error {
+	var paths []string
+	var postFunc WalkFunc
+	if post != nil {
+		postFunc = func(node *Node, _ int) error {
+			post(node)
+			return nil
+		}
+	}
+	return n.Walk(func(node *Node, depth int) error {
+		rel := ""
+		if depth > 1 {
+			rel = paths[depth-1] + "/" + node.Name
+		} else if depth == 1 {
+			rel = node.Name
+		}
+		paths = append(paths[:depth], rel)
+		return pre(node, rel)
+	}, postFunc)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if glob.Match(strings.Trim(pattern, "/"), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPattern reports malformed patterns, which glob.Match treats as
+// never matching
+func checkPattern(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package tree_test
+
+import (
+	"errors"
+	"path"
+	"testing"
+)
+
+const filterSpec = `app/
+├── src/
+│   ├── main.go
+│   ├── main_test.go
+│   └── util/
+│       ├── util.go
+│       └── util_test.go
+├── docs/
+│   └── guide.md
+├── testdata/
+└── go.mod`
+
+func TestNode_Filter(t *testing.T) {
+	tests := []struct {
+		only     []string
+		exclude  []string
+		expected string
+	}{
+		{nil, nil, "app\n src\n  main.go\n  main_test.go\n  util\n   util.go\n   util_test.go\n docs\n  guide.md\n testdata\n go.mod\n"},
+		{[]string{"src/**"}, nil, "app\n src\n  main.go\n  main_test.go\n  util\n   util.go\n   util_test.go\n"},
+		{[]string{"src"}, nil, "app\n src\n  main.go\n  main_test.go\n  util\n   util.go\n   util_test.go\n"},
+		{[]string{"**/*.go"}, nil, "app\n src\n  main.go\n  main_test.go\n  util\n   util.go\n   util_test.go\n"},
+		{[]string{"src/util/util.go", "docs/"}, nil, "app\n src\n  util\n   util.go\n docs\n  guide.md\n"},
+		{nil, []string{"**/*_test.go"}, "app\n src\n  main.go\n  util\n   util.go\n docs\n  guide.md\n testdata\n go.mod\n"},
+		{nil, []string{"src/util", "testdata"}, "app\n src\n  main.go\n  main_test.go\n docs\n  guide.md\n go.mod\n"},
+		{[]string{"src/**"}, []string{"**/*_test.go"}, "app\n src\n  main.go\n  util\n   util.go\n"},
+		{[]string{"missing/**"}, nil, "app\n"},
+	}
+
+	for _, tt := range tests {
+		root := mustParse(t, filterSpec)
+		if err := root.Filter(tt.only, tt.exclude); err != nil {
+			t.Fatalf("only %v, exclude %v: %v", tt.only, tt.exclude, err)
+		}
+		if got := names(root); got != tt.expected {
+			t.Errorf("only %v, exclude %v: expected\n%s\ngot\n%s", tt.only, tt.exclude, tt.expected, got)
+		}
+	}
+}
+
+func TestNode_FilterInvalidPattern(t *testing.T) {
+	root := mustParse(t, filterSpec)
+	if err := root.Filter(nil, []string{"src/[a-"}); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("Expected ErrBadPattern, got %v", err)
+	}
+}
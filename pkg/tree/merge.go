@@ -0,0 +1,154 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTypeConflict is returned when merging a file with a directory of the same path
+	ErrTypeConflict = errors.New("file and directory with the same path")
+	// ErrContentConflict is returned when merging files with different contents
+	ErrContentConflict = errors.New("files with different contents")
+)
+
+// MergePolicy decides a conflict between two nodes at the same path
+type MergePolicy string
+
+const (
+	MergeError MergePolicy = "error" // Fail with a *ConflictError
+	MergeFirst MergePolicy = "first" // Keep the node already in the tree
+	MergeLast  MergePolicy = "last"  // Take the node being merged in
+)
+
+// MergePolicies lists all merge policies
+var MergePolicies = []MergePolicy{MergeError, MergeFirst, MergeLast}
+
+// MergeOptions set how conflicts are resolved. The zero value fails on
+// type conflicts and lets later contents win.
+type MergeOptions struct {
+	// Types applies when a path is a file in one tree and a directory in
+	// the other (default MergeError)
+	Types MergePolicy
+	// Contents applies when both trees give a file different, non-empty
+	// contents (default MergeLast). A file without content never conflicts.
+	Contents MergePolicy
+}
+
+// ConflictError reports a conflict under the MergeError policy
+type ConflictError struct {
+	Path  string // Slash-separated path of the node in the tree merged into
+	Line  int    // Line of the conflicting node in the tree merged in
+	File  string // Spec file of the conflicting node, if known
+	Cause error  // ErrTypeConflict or ErrContentConflict
+}
+
+func (e *ConflictError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s: %v (%s:%d)", e.Path, e.Cause, e.File, e.Line)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Cause)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Cause
+}
+
+// Merge copies the children of other into n, with the default MergeOptions
+func (n *Node) Merge(other *Node) error {
+	return n.MergeWith(other, MergeOptions{})
+}
+
+// MergeWith copies the children of other into n, matching nodes by name
+// at every level. Directories with the same path are merged; other
+// conflicts are resolved by opts. other is left unchanged.
+func (n *Node) MergeWith(other *Node, opts MergeOptions) error {
+	if opts.Types == "" {
+		opts.Types = MergeError
+	}
+	if opts.Contents == "" {
+		opts.Contents = MergeLast
+	}
+	for _, policy := range []MergePolicy{opts.Types, opts.Contents} {
+		if policy != MergeError && policy != MergeFirst && policy != MergeLast {
+			return fmt.Errorf("unknown merge policy %q", policy)
+		}
+	}
+
+	if other.Comment != "" {
+		n.Comment = other.Comment
+	}
+
+	// Merge level by level to avoid recursion on deep trees
+	pairs := [][2]*Node{{n, other}}
+	for len(pairs) > 0 {
+		dst, src := pairs[0][0], pairs[0][1]
+		pairs = pairs[1:]
+		for _, child := range src.Children {
+			existing := dst.Child(child.Name)
+			switch {
+			case existing == nil:
+				if err := dst.Insert(child.Clone()); err != nil {
+					return err
+				}
+
+			case existing.IsDir != child.IsDir:
+				switch opts.Types {
+				case MergeError:
+					return conflict(existing, child, ErrTypeConflict)
+				case MergeLast:
+					existing.replace(child.Clone())
+				}
+
+			case existing.IsDir:
+				if child.Comment != "" {
+					existing.Comment = child.Comment
+				}
+				pairs = append(pairs, [2]*Node{existing, child})
+
+			default:
+				if err := mergeFile(existing, child, opts.Contents); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mergeFile merges the file src into the file dst
+func mergeFile(dst, src *Node, policy MergePolicy) error {
+	if dst.Content != "" && src.Content != "" && dst.Content != src.Content {
+		switch policy {
+		case MergeError:
+			return conflict(dst, src, ErrContentConflict)
+		case MergeFirst:
+			return nil
+		}
+	}
+	if src.Content != "" {
+		dst.Content = src.Content
+	}
+	if src.Comment != "" {
+		dst.Comment = src.Comment
+	}
+	return nil
+}
+
+// replace puts replacement in the place of n in its parent's children
+func (n *Node) replace(replacement *Node) {
+	parent := n.parent
+	for i, child := range parent.Children {
+		if child == n {
+			parent.Children[i] = replacement
+			break
+		}
+	}
+	replacement.parent = parent
+	shiftLevel(replacement, n.Level-replacement.Level)
+	n.parent = nil
+}
+
+func conflict(existing, node *Node, cause error) *ConflictError {
+	return &ConflictError{Path: existing.Path(), Line: node.Line, File: node.File, Cause: cause}
+}
@@ -0,0 +1,108 @@
+package tree_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+func TestNode_Merge(t *testing.T) {
+	root := mustParse(t, nodeSpec)
+	overlay := mustParse(t, "app/\n├── src/\n│   ├── main.go\n│   └── util.go\n└── README.md")
+	overlay.Find("src/main.go").Content = "package main\n"
+
+	if err := root.Merge(overlay); err != nil {
+		t.Fatal(err)
+	}
+	expected := "app\n src\n  main.go\n  main_test.go\n  util.go\n test\n  fixtures\n go.mod\n README.md\n"
+	if names(root) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, names(root))
+	}
+	main := root.Find("src/main.go")
+	if main.Content != "package main\n" || main.Parent() != root.Find("src") || main == overlay.Find("src/main.go") {
+		t.Errorf("Expected a copy of the overlay's main.go, got %+v", main)
+	}
+	if util := root.Find("src/util.go"); util.Path() != "app/src/util.go" || util.Level != 2 {
+		t.Errorf("Unexpected merged node %+v", util)
+	}
+
+	conflict := mustParse(t, "app/\n└── go.mod/\n    └── x")
+	if err := root.Merge(conflict); !errors.Is(err, tree.ErrTypeConflict) {
+		t.Errorf("Expected tree.ErrTypeConflict, got %v", err)
+	}
+}
+
+func TestNode_MergeWith(t *testing.T) {
+	base := "app/\n├── config/\n└── main.go"
+	overlay := "app/\n├── config\n└── main.go/\n    └── x.go"
+
+	tests := []struct {
+		opts     tree.MergeOptions
+		expected string
+		err      error
+	}{
+		{tree.MergeOptions{}, "", tree.ErrTypeConflict},
+		{tree.MergeOptions{Types: tree.MergeFirst}, "app\n config\n main.go\n", nil},
+		{tree.MergeOptions{Types: tree.MergeLast}, "app\n config\n main.go\n  x.go\n", nil},
+		{tree.MergeOptions{Types: "newest"}, "", nil},
+	}
+
+	for _, tt := range tests {
+		root := mustParse(t, base)
+		err := root.MergeWith(mustParse(t, overlay), tt.opts)
+		if tt.expected == "" {
+			if err == nil || tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("%+v: expected error %v, got %v", tt.opts, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.opts, err)
+		}
+		if names(root) != tt.expected {
+			t.Errorf("%+v: expected\n%s\ngot\n%s", tt.opts, tt.expected, names(root))
+		}
+	}
+}
+
+func TestNode_MergeContents(t *testing.T) {
+	newTree := func(content string) *tree.Node {
+		root := &tree.Node{Name: "app", IsDir: true}
+		root.Insert(&tree.Node{Name: "a.txt", Content: content, Line: 2, File: "overlay.tree"})
+		return root
+	}
+
+	tests := []struct {
+		policy   tree.MergePolicy
+		base     string
+		overlay  string
+		expected string
+	}{
+		{"", "one", "two", "two"},
+		{tree.MergeFirst, "one", "two", "one"},
+		{tree.MergeLast, "one", "two", "two"},
+		{tree.MergeError, "one", "", "one"},
+		{tree.MergeError, "", "two", "two"},
+		{tree.MergeError, "same", "same", "same"},
+	}
+
+	for _, tt := range tests {
+		root := newTree(tt.base)
+		if err := root.MergeWith(newTree(tt.overlay), tree.MergeOptions{Contents: tt.policy}); err != nil {
+			t.Fatalf("%+v: %v", tt, err)
+		}
+		if got := root.Find("a.txt").Content; got != tt.expected {
+			t.Errorf("%+v: expected content %q, got %q", tt, tt.expected, got)
+		}
+	}
+
+	err := newTree("one").MergeWith(newTree("two"), tree.MergeOptions{Contents: tree.MergeError})
+	var conflict *tree.ConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, tree.ErrContentConflict) {
+		t.Fatalf("Expected a content tree.ConflictError, got %v", err)
+	}
+	if err.Error() != "app/a.txt: files with different contents (overlay.tree:2)" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
@@ -0,0 +1,206 @@
+// Package tree is the model of a structure spec: a tree of Node values
+// with methods to walk, edit, filter and merge it. Package buildtree
+// parses specs into this model and builds it.
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrExists is returned when a node already has a child with the same name
+	ErrExists = errors.New("name already exists")
+	// ErrNotDir is returned when adding children to a file
+	ErrNotDir = errors.New("not a directory")
+	// ErrInvalidName is returned for empty names and names containing "/"
+	ErrInvalidName = errors.New("invalid name")
+	// ErrCycle is returned when inserting a node into itself or one of its
+	// descendants
+	ErrCycle = errors.New("node would contain itself")
+)
+
+// CurrentDir names the root of a spec without a single top-level
+// directory, such as a diagram starting with "." or listing several
+// roots. Its children are built in the output directory.
+const CurrentDir = "."
+
+// Node is a file or directory of a spec
+type Node struct {
+	Name      string
+	IsDir     bool
+	Level     int
+	Line      int    // Line number in the input, starting at 1
+	File      string // Spec file the node was read from, if known
+	Comment   string // Text of a trailing "#" comment, if any
+	Content   string // Initial file content, if the spec provides one
+	Directive string // Keyword of an "@" directive line; Name holds its argument
+	Children  []*Node
+
+	parent *Node
+}
+
+// Parent returns the directory n is in, or nil for a root. Parent pointers
+// are set when a tree is parsed or expanded and kept up to date by the
+// methods of Node; after editing Children directly, call SetParents.
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+// SetParents sets the parent pointers of all nodes below n from their
+// Children lists
+func (n *Node) SetParents() {
+	n.Walk(func(node *Node, _ int) error {
+		for _, child := range node.Children {
+			child.parent = node
+		}
+		return nil
+	}, nil)
+}
+
+// Path returns the slash-separated path of n from its root, including the
+// root's name
+func (n *Node) Path() string {
+	var names []string
+	for node := n; node != nil; node = node.parent {
+		names = append(names, node.Name)
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, "/")
+}
+
+// Child returns the child of n with the given name, or nil
+func (n *Node) Child(name string) *Node {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Find returns the node at the slash-separated path relative to n, or nil.
+// An empty path or "." returns n.
+func (n *Node) Find(path string) *Node {
+	node := n
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		if name == "" || name == "." {
+			continue
+		}
+		if node = node.Child(name); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// Insert adds child as the last child of n, moving it out of its previous
+// parent. Levels below child are updated to match their new depth.
+func (n *Node) Insert(child *Node) error {
+	if !n.IsDir {
+		return fmt.Errorf("%w: %s", ErrNotDir, n.Path())
+	}
+	if err := checkName(child.Name); err != nil {
+		return err
+	}
+	if existing := n.Child(child.Name); existing != nil && existing != child {
+		return fmt.Errorf("%w: %s/%s", ErrExists, n.Path(), child.Name)
+	}
+	for ancestor := n; ancestor != nil; ancestor = ancestor.parent {
+		if ancestor == child {
+			return fmt.Errorf("%w: %s into %s", ErrCycle, child.Path(), n.Path())
+		}
+	}
+
+	child.Remove()
+	child.parent = n
+	n.Children = append(n.Children, child)
+	shiftLevel(child, n.Level+1-child.Level)
+	return nil
+}
+
+// Remove detaches n from its parent. It does nothing for a root.
+func (n *Node) Remove() {
+	parent := n.parent
+	if parent == nil {
+		return
+	}
+	for i, child := range parent.Children {
+		if child == n {
+			parent.Children = append(parent.Children[:i:i], parent.Children[i+1:]...)
+			break
+		}
+	}
+	n.parent = nil
+}
+
+// Rename changes the name of n, refusing names already used by a sibling
+func (n *Node) Rename(name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if n.parent != nil {
+		if existing := n.parent.Child(name); existing != nil && existing != n {
+			return fmt.Errorf("%w: %s/%s", ErrExists, n.parent.Path(), name)
+		}
+	}
+	n.Name = name
+	return nil
+}
+
+// Clone returns a deep copy of n and its descendants. The copy is a root:
+// its parent is nil.
+func (n *Node) Clone() *Node {
+	clone := *n
+	clone.parent = nil
+	clone.Children = nil
+
+	// Copy level by level to avoid recursion on deep trees
+	pairs := [][2]*Node{{n, &clone}}
+	for len(pairs) > 0 {
+		original, copied := pairs[0][0], pairs[0][1]
+		pairs = pairs[1:]
+		for _, child := range original.Children {
+			c := *child
+			c.parent = copied
+			c.Children = nil
+			copied.Children = append(copied.Children, &c)
+			pairs = append(pairs, [2]*Node{child, &c})
+		}
+	}
+	return &clone
+}
+
+// Sort orders the children of n and all its descendants with directories
+// first, then by name
+func (n *Node) Sort() {
+	n.Walk(func(node *Node, _ int) error {
+		sort.SliceStable(node.Children, func(i, j int) bool {
+			a, b := node.Children[i], node.Children[j]
+			if a.IsDir != b.IsDir {
+				return a.IsDir
+			}
+			return a.Name < b.Name
+		})
+		return nil
+	}, nil)
+}
+
+func checkName(name string) error {
+	if name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// shiftLevel adds delta to the level of node and all its descendants
+func shiftLevel(node *Node, delta int) {
+	node.Walk(func(n *Node, _ int) error {
+		n.Level += delta
+		return nil
+	}, nil)
+}
@@ -0,0 +1,177 @@
+package tree_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/neomen/buildtree/internal/parser"
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+const nodeSpec = `app/
+├── src/
+│   ├── main.go
+│   └── main_test.go
+├── test/
+│   └── fixtures/
+└── go.mod`
+
+func TestNode_PathAndFind(t *testing.T) {
+	root := mustParse(t, nodeSpec)
+
+	node := root.Find("src/main.go")
+	if node == nil {
+		t.Fatal("Expected to find src/main.go")
+	}
+	if node.Path() != "app/src/main.go" || node.Parent() != root.Find("src") {
+		t.Errorf("Unexpected path %q or parent %v", node.Path(), node.Parent())
+	}
+	if root.Find("") != root || root.Find("./src/") != root.Find("src") {
+		t.Error("Expected empty and dotted paths to resolve")
+	}
+	if root.Find("src/missing.go") != nil || root.Find("go.mod/x") != nil {
+		t.Error("Expected missing paths to return nil")
+	}
+	if root.Parent() != nil || root.Path() != "app" {
+		t.Errorf("Unexpected root parent %v or path %q", root.Parent(), root.Path())
+	}
+}
+
+func TestNode_Insert(t *testing.T) {
+	root := mustParse(t, nodeSpec)
+
+	if err := root.Insert(&tree.Node{Name: "LICENSE"}); err != nil {
+		t.Fatal(err)
+	}
+	if license := root.Find("LICENSE"); license == nil || license.Level != 1 || license.Path() != "app/LICENSE" {
+		t.Errorf("Unexpected inserted node %+v", license)
+	}
+
+	// Moving a subtree updates its parent and levels
+	test := root.Find("test")
+	if err := root.Find("src").Insert(test); err != nil {
+		t.Fatal(err)
+	}
+	if root.Find("test") != nil || root.Find("src/test/fixtures").Level != 3 || test.Parent() != root.Find("src") {
+		t.Errorf("Expected test/ to move under src/, got\n%s", names(root))
+	}
+
+	if err := root.Insert(&tree.Node{Name: "go.mod"}); !errors.Is(err, tree.ErrExists) {
+		t.Errorf("Expected tree.ErrExists, got %v", err)
+	}
+	if err := root.Find("go.mod").Insert(&tree.Node{Name: "x"}); !errors.Is(err, tree.ErrNotDir) {
+		t.Errorf("Expected tree.ErrNotDir, got %v", err)
+	}
+	if err := root.Insert(&tree.Node{Name: "a/b"}); !errors.Is(err, tree.ErrInvalidName) {
+		t.Errorf("Expected tree.ErrInvalidName, got %v", err)
+	}
+}
+
+func TestNode_InsertCycle(t *testing.T) {
+	root := mustParse(t, nodeSpec)
+	test := root.Find("test")
+	before := names(root)
+
+	for _, target := range []*tree.Node{test, root.Find("test/fixtures")} {
+		if err := target.Insert(test); !errors.Is(err, tree.ErrCycle) {
+			t.Errorf("Inserting test into %s: expected tree.ErrCycle, got %v", target.Path(), err)
+		}
+	}
+	if test.Parent() != root || names(root) != before {
+		t.Errorf("Expected the tree to be unchanged, got\n%s", names(root))
+	}
+}
+
+func TestNode_RemoveDuringWalk(t *testing.T) {
+	root := mustParse(t, nodeSpec)
+
+	// Strip test files and directories
+	err := root.Walk(func(node *tree.Node, _ int) error {
+		if node.Name == "test" || strings.HasSuffix(node.Name, "_test.go") {
+			node.Remove()
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "app\n src\n  main.go\n go.mod\n"; names(root) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, names(root))
+	}
+
+	root.Remove() // A root has nothing to be removed from
+}
+
+func TestNode_Rename(t *testing.T) {
+	root := mustParse(t, nodeSpec)
+	node := root.Find("src")
+
+	if err := node.Rename("cmd"); err != nil {
+		t.Fatal(err)
+	}
+	if root.Find("cmd/main.go").Path() != "app/cmd/main.go" {
+		t.Errorf("Unexpected tree after rename\n%s", names(root))
+	}
+	if err := node.Rename("go.mod"); !errors.Is(err, tree.ErrExists) {
+		t.Errorf("Expected tree.ErrExists, got %v", err)
+	}
+	if err := node.Rename(""); !errors.Is(err, tree.ErrInvalidName) {
+		t.Errorf("Expected tree.ErrInvalidName, got %v", err)
+	}
+}
+
+func TestNode_Clone(t *testing.T) {
+	root := mustParse(t, nodeSpec)
+	src := root.Find("src")
+
+	clone := src.Clone()
+	if clone.Parent() != nil || clone.Path() != "src" || clone.Find("main.go").Path() != "src/main.go" {
+		t.Errorf("Unexpected clone %s", names(clone))
+	}
+	clone.Find("main.go").Name = "changed.go"
+	if src.Find("main.go") == nil {
+		t.Error("Editing the clone changed the original")
+	}
+}
+
+func TestNode_Sort(t *testing.T) {
+	root := mustParse(t, "app/\n├── b.txt\n├── z/\n│   ├── b\n│   └── a\n├── a.txt\n└── m/")
+	root.Sort()
+
+	if expected := "app\n m\n z\n  a\n  b\n a.txt\n b.txt\n"; names(root) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, names(root))
+	}
+}
+
+func TestNode_DeepClone(t *testing.T) {
+	root := chain(100000)
+	clone := root.Clone()
+
+	depth := 0
+	for node := clone; len(node.Children) > 0; node = node.Children[0] {
+		depth++
+	}
+	if depth != 100000 || clone == root {
+		t.Errorf("Expected a copy 100000 levels deep, got %d", depth)
+	}
+}
+
+func mustParse(t *testing.T, input string) *tree.Node {
+	t.Helper()
+	root, err := parser.ParseInput(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// names lists the tree one name per line, indented by depth
+func names(root *tree.Node) string {
+	var b strings.Builder
+	root.Walk(func(node *tree.Node, depth int) error {
+		b.WriteString(strings.Repeat(" ", depth) + node.Name + "\n")
+		return nil
+	}, nil)
+	return b.String()
+}
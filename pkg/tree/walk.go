@@ -0,0 +1,66 @@
+package tree
+
+import "errors"
+
+// SkipSubtree is returned by the pre-order function of Walk to skip the
+// node's descendants and its post-order visit
+var SkipSubtree = errors.New("skip subtree")
+
+// WalkFunc visits a node depth levels below the node the walk started at
+type WalkFunc func(node *Node, depth int) error
+
+// Walk visits n and its descendants in order, calling pre before a node's
+// children and post after them; either may be nil. It keeps its own stack
+// instead of recursing, so the depth of the tree is not limited by the
+// goroutine stack. If pre returns SkipSubtree the walk moves on to the
+// next sibling, and from post it is ignored; any other error stops the
+// walk and is returned. pre may Remove the node it is called with, which
+// also skips the node's subtree.
+func (n *Node) Walk(pre, post WalkFunc) error {
+	type frame struct {
+		node  *Node
+		depth int
+		next  int // Index of the next child to visit
+	}
+
+	if pre != nil {
+		if err := pre(n, 0); err == SkipSubtree {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	stack := []frame{{node: n}}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.next < len(top.node.Children) {
+			child := top.node.Children[top.next]
+			top.next++
+			depth := top.depth + 1
+			if pre != nil {
+				if err := pre(child, depth); err == SkipSubtree {
+					continue
+				} else if err != nil {
+					return err
+				}
+				// pre removed the child: its next sibling moved into its place
+				if top.next > len(top.node.Children) || top.node.Children[top.next-1] != child {
+					top.next--
+					continue
+				}
+			}
+			stack = append(stack, frame{node: child, depth: depth})
+			continue
+		}
+
+		done := *top
+		stack = stack[:len(stack)-1]
+		if post != nil {
+			if err := post(done.node, done.depth); err != nil && err != SkipSubtree {
+				return err
+			}
+		}
+	}
+	return nil
+}
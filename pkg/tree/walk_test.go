@@ -0,0 +1,143 @@
+package tree_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neomen/buildtree/pkg/tree"
+)
+
+func TestWalk_Order(t *testing.T) {
+	root := &tree.Node{Name: "root", IsDir: true, Children: []*tree.Node{
+		{Name: "a", IsDir: true, Children: []*tree.Node{
+			{Name: "a1"},
+			{Name: "a2"},
+		}},
+		{Name: "b"},
+	}}
+
+	var visits []string
+	err := root.Walk(func(node *tree.Node, depth int) error {
+		visits = append(visits, "pre "+node.Name+" "+strings.Repeat(".", depth))
+		return nil
+	}, func(node *tree.Node, depth int) error {
+		visits = append(visits, "post "+node.Name+" "+strings.Repeat(".", depth))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"pre root ", "pre a .", "pre a1 ..", "post a1 ..", "pre a2 ..", "post a2 ..",
+		"post a .", "pre b .", "post b .", "post root ",
+	}
+	if !reflect.DeepEqual(visits, expected) {
+		t.Errorf("Expected visits %v, got %v", expected, visits)
+	}
+}
+
+func TestWalk_SkipSubtree(t *testing.T) {
+	root := &tree.Node{Name: "root", IsDir: true, Children: []*tree.Node{
+		{Name: "skip", IsDir: true, Children: []*tree.Node{{Name: "hidden"}}},
+		{Name: "keep", IsDir: true, Children: []*tree.Node{{Name: "shown"}}},
+	}}
+
+	var pre, post []string
+	err := root.Walk(func(node *tree.Node, _ int) error {
+		pre = append(pre, node.Name)
+		if node.Name == "skip" {
+			return tree.SkipSubtree
+		}
+		return nil
+	}, func(node *tree.Node, _ int) error {
+		post = append(post, node.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"root", "skip", "keep", "shown"}; !reflect.DeepEqual(pre, expected) {
+		t.Errorf("Expected pre-order %v, got %v", expected, pre)
+	}
+	if expected := []string{"shown", "keep", "root"}; !reflect.DeepEqual(post, expected) {
+		t.Errorf("Expected post-order %v, got %v", expected, post)
+	}
+
+	// Skipping the starting node ends the walk without an error
+	calls := 0
+	err = root.Walk(func(*tree.Node, int) error {
+		calls++
+		return tree.SkipSubtree
+	}, func(*tree.Node, int) error {
+		calls++
+		return nil
+	})
+	if err != nil || calls != 1 {
+		t.Errorf("Expected one call and no error, got %d calls and %v", calls, err)
+	}
+}
+
+func TestWalk_Error(t *testing.T) {
+	root := &tree.Node{Name: "root", IsDir: true, Children: []*tree.Node{
+		{Name: "a"},
+		{Name: "b"},
+		{Name: "c"},
+	}}
+	stop := errors.New("stop")
+
+	var visited []string
+	err := root.Walk(nil, func(node *tree.Node, _ int) error {
+		visited = append(visited, node.Name)
+		if node.Name == "b" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("Expected the post-order error, got %v", err)
+	}
+	if expected := []string{"a", "b"}; !reflect.DeepEqual(visited, expected) {
+		t.Errorf("Expected the walk to stop after %v, got %v", expected, visited)
+	}
+}
+
+func TestWalk_DeepTree(t *testing.T) {
+	const depth = 100000
+	root := chain(depth)
+
+	pre, post, maxDepth := 0, 0, 0
+	lastPost := -1
+	err := root.Walk(func(node *tree.Node, d int) error {
+		pre++
+		maxDepth = max(maxDepth, d)
+		return nil
+	}, func(node *tree.Node, d int) error {
+		post++
+		if lastPost != -1 && d != lastPost-1 {
+			t.Fatalf("Post-order visit at depth %d after depth %d", d, lastPost)
+		}
+		lastPost = d
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pre != depth+1 || post != depth+1 || maxDepth != depth {
+		t.Errorf("Expected %d visits down to depth %d, got %d pre, %d post, depth %d", depth+1, depth, pre, post, maxDepth)
+	}
+}
+
+// chain returns a tree of nested directories depth levels deep
+func chain(depth int) *tree.Node {
+	root := &tree.Node{Name: "d", IsDir: true}
+	node := root
+	for i := 1; i <= depth; i++ {
+		child := &tree.Node{Name: "d", IsDir: true, Level: i}
+		node.Children = []*tree.Node{child}
+		node = child
+	}
+	return root
+}